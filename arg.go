@@ -12,6 +12,7 @@ type ArgBuilder struct {
 	newArg  func() (di.Arg, error)
 	slot    uint
 	slotSet bool
+	reverse bool
 }
 
 // Arg returns an argument builder appropriate for the given value.
@@ -22,6 +23,9 @@ func Arg(v any) *ArgBuilder {
 	if ref, ok := v.(*SvcReference); ok {
 		return Ref(ref)
 	}
+	if ref, ok := v.(interface{ Arg() *ArgBuilder }); ok {
+		return ref.Arg() // *TypedRef[T]
+	}
 	return Val(v)
 }
 
@@ -33,11 +37,23 @@ func (b *ArgBuilder) Slot(n uint) *ArgBuilder {
 	return b
 }
 
+// Reverse reverses the order of a slice argument, e.g. SliceOf[T]().Reverse().
+func (b *ArgBuilder) Reverse() *ArgBuilder {
+	b.reverse = true
+	return b
+}
+
 func (b *ArgBuilder) Build() (di.Arg, error) {
 	arg, err := b.newArg()
 	if err != nil {
 		return nil, err
 	}
+	if b.reverse {
+		arg, err = di.NewReversedArg(arg)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if b.slotSet {
 		return di.NewSlottedArg(arg, b.slot), nil
 	}
@@ -70,6 +86,13 @@ func Type[T any](label ...Label) *ArgBuilder {
 	}}
 }
 
+// Named returns an argument builder for a reference to a service by its name.
+func Named[T any](name string) *ArgBuilder {
+	return &ArgBuilder{newArg: func() (di.Arg, error) {
+		return di.NewNameArg(name, reflect.TypeFor[T]()), nil
+	}}
+}
+
 // SliceOf returns an argument builder for a typed reference to a slice.
 func SliceOf[T any](label ...Label) *ArgBuilder {
 	if len(label) > 0 {
@@ -82,6 +105,24 @@ func SliceOf[T any](label ...Label) *ArgBuilder {
 	}}
 }
 
+// MapOf returns an argument builder for a map of services of type V, keyed by their keys (see ServiceDefinitionBuilder.Key).
+// Services without a key (or a name) are skipped, and duplicate keys fail the validation.
+// The keyFn converts the service key to the map key. It can be nil if K is a string type.
+// Optionally, a label can be used to narrow down the services.
+func MapOf[K comparable, V any](keyFn func(key string) K, label ...Label) *ArgBuilder {
+	return &ArgBuilder{newArg: func() (di.Arg, error) {
+		var anyKeyFn func(string) any
+		if keyFn != nil {
+			anyKeyFn = func(key string) any { return keyFn(key) }
+		}
+		var l Label
+		if len(label) > 0 {
+			l = label[len(label)-1]
+		}
+		return di.NewMapArg(reflect.TypeFor[map[K]V](), anyKeyFn, l)
+	}}
+}
+
 // Compound returns an argument builder for an argument composed of other arguments.
 func Compound[T any](builders ...*ArgBuilder) *ArgBuilder {
 	return &ArgBuilder{newArg: func() (di.Arg, error) {
@@ -2,6 +2,11 @@ package di
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"slices"
 
 	"github.com/michalkurzeja/godi/v2/di"
 )
@@ -23,6 +28,11 @@ type Builder struct {
 	functions []*FunctionDefinitionBuilder
 	bindings  []*InterfaceBindingBuilder
 	passes    []*di.CompilerPass
+
+	roots     []any
+	keepEager bool
+
+	err error // Errors of the definitions added to the container builder so far.
 }
 
 func (b *Builder) Services(services ...*ServiceDefinitionBuilder) *Builder {
@@ -45,8 +55,92 @@ func (b *Builder) CompilerPasses(passes ...*di.CompilerPass) *Builder {
 	return b
 }
 
+// Roots restricts the container to the definitions that are reachable from the given roots.
+// Everything else is dropped before validation, so it doesn't have to be configured correctly,
+// and it's not instantiated even if it's eager (unless KeepEager is used).
+// A root can be:
+//   - a service reference (SvcReference or *SvcReference),
+//   - a function reference (FuncReference or *FuncReference),
+//   - a type (reflect.Type) - matches all services and functions of that type,
+//   - a Label - matches all services and functions with that label.
+func (b *Builder) Roots(roots ...any) *Builder {
+	b.roots = append(b.roots, roots...)
+	return b
+}
+
+// KeepEager makes all eager services and functions roots, so they survive the pruning done when Roots are used.
+func (b *Builder) KeepEager() *Builder {
+	b.keepEager = true
+	return b
+}
+
 func (b *Builder) Build() (Container, error) {
-	var joinedErr error
+	c, _, err := b.BuildWithReport()
+	return c, err
+}
+
+type Warning = di.Warning
+
+// BuildReport contains the non-fatal findings of the build.
+type BuildReport struct {
+	Warnings []Warning
+}
+
+// BuildWithReport works like Build, but it additionally returns a report of the build.
+// The report is returned even if the build fails.
+func (b *Builder) BuildWithReport() (Container, BuildReport, error) {
+	joinedErr := b.prepare()
+
+	if len(b.roots) > 0 {
+		b.cb.Compiler().AddPass(di.NewCompilerPass("pruning", di.PreValidation, di.NewPruningPass(b.resolveRoots)))
+	}
+
+	container, err := b.cb.Build()
+	return container, BuildReport{Warnings: b.cb.Warnings()}, errors.Join(joinedErr, err)
+}
+
+// Clone returns a deep copy of the builder. Both builders can be extended and built independently,
+// e.g. to build a container per test case or per tenant without defining everything from scratch.
+// Cloning builds all definitions registered so far, so their errors are returned here.
+// References bound so far keep working with both builders, as the copies keep the IDs of the definitions.
+func (b *Builder) Clone() (*Builder, error) {
+	if err := b.prepare(); err != nil {
+		return nil, err
+	}
+	cb, err := b.cb.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return &Builder{cb: cb, roots: slices.Clone(b.roots), keepEager: b.keepEager}, nil
+}
+
+// Derive returns a builder with a deep copy of the definitions of the container, that can be extended
+// and built into a new, independent container. It's useful to override a few services of a fully
+// configured container, e.g. in tests. The compiler passes of the container are not copied,
+// as they have already been applied to the definitions.
+// Use di.ReuseInstances to share the already instantiated, unchanged services with the container.
+func Derive(c Container, opts ...DeriveOption) (*Builder, error) {
+	deriver, ok := c.(interface {
+		DerivedBuilder(opts ...di.DeriveOption) *di.ContainerBuilder
+	})
+	if !ok {
+		return nil, fmt.Errorf("container of type %T cannot be derived", c)
+	}
+	return &Builder{cb: deriver.DerivedBuilder(opts...)}, nil
+}
+
+type DeriveOption = di.DeriveOption
+
+// ReuseInstances makes the derived container reuse the instances of shared services from the parent container,
+// as long as the services and all their dependencies are defined the same way in both containers.
+func ReuseInstances() DeriveOption {
+	return di.ReuseInstances()
+}
+
+// prepare adds all registered definitions, bindings and compiler passes to the container builder.
+// It returns the errors of all definitions prepared so far.
+func (b *Builder) prepare() error {
+	joinedErr := b.err
 
 	for _, builder := range b.services {
 		if err := builder.ParseFactory(); err != nil {
@@ -80,8 +174,62 @@ func (b *Builder) Build() (Container, error) {
 		b.cb.Compiler().AddPass(pass)
 	}
 
-	container, err := b.cb.Build()
-	return container, errors.Join(joinedErr, err)
+	b.services, b.functions, b.bindings, b.passes = nil, nil, nil, nil
+	b.err = joinedErr
+
+	return joinedErr
+}
+
+func (b *Builder) resolveRoots(builder *di.ContainerBuilder) (ids []ID, joinedErr error) {
+	root := builder.RootScope()
+	for _, r := range b.roots {
+		switch ref := r.(type) {
+		case *SvcReference:
+			r = *ref
+		case *FuncReference:
+			r = *ref
+		}
+
+		var rootIDs []ID
+		switch r := r.(type) {
+		case SvcReference:
+			if !r.IsEmpty() {
+				rootIDs = []ID{r.SvcID()}
+			}
+		case FuncReference:
+			if !r.IsEmpty() {
+				rootIDs = []ID{r.FuncID()}
+			}
+		case reflect.Type:
+			rootIDs = append(di.ResolveArgIDs(root, di.NewTypeArg(r, true)), root.GetFunctionsIDsByType(r)...)
+		case Label:
+			builder.UseLabels(r)
+			rootIDs = append(root.GetServicesIDsByLabel(r), root.GetFunctionsIDsByLabel(r)...)
+		default:
+			joinedErr = errors.Join(joinedErr, fmt.Errorf("unsupported root %v of type %T", r, r))
+			continue
+		}
+		if len(rootIDs) == 0 {
+			joinedErr = errors.Join(joinedErr, fmt.Errorf("root %v matches no definitions", r))
+			continue
+		}
+		ids = append(ids, rootIDs...)
+	}
+
+	if b.keepEager {
+		for _, def := range builder.ServiceDefinitionsSeq() {
+			if !def.IsLazy() {
+				ids = append(ids, def.ID())
+			}
+		}
+		for _, def := range builder.FunctionDefinitionsSeq() {
+			if !def.IsLazy() {
+				ids = append(ids, def.ID())
+			}
+		}
+	}
+
+	return ids, joinedErr
 }
 
 func newConfig(opts []BuilderOption) di.Config {
@@ -99,3 +247,61 @@ func SkipCycleValidation() BuilderOption {
 		b.CompilerConfig.SkipCycleValidation = true
 	}
 }
+
+// SkipResolutionPlanning makes the builder skip precompiling the resolution plans of the definitions.
+// It makes the build faster, at the cost of slower instantiation of services and execution of functions.
+func SkipResolutionPlanning() BuilderOption {
+	return func(b *di.Config) {
+		b.CompilerConfig.SkipResolutionPlanning = true
+	}
+}
+
+// DeterministicIDs makes the builder derive the IDs of definitions from the scope path,
+// the factory (or function) name and the registration index, instead of generating random ones.
+// The IDs are stable across runs of the same binary, so e.g. the Print output can be snapshot-tested.
+func DeterministicIDs() BuilderOption {
+	return func(b *di.Config) {
+		b.DeterministicIDs = true
+	}
+}
+
+// CollectStats makes the container collect the runtime counters of the definitions,
+// see di.Container.Stats. It adds a small cost to every resolution, so it's disabled by default.
+func CollectStats() BuilderOption {
+	return func(b *di.Config) {
+		b.Stats = true
+	}
+}
+
+// ReportUnused makes the builder report unused services as warnings, and write them to w (if not nil).
+// A service is unused if it's lazy, it's not labelled as an EntryPoint, and no other
+// service, function or interface binding depends on it.
+func ReportUnused(w io.Writer) BuilderOption {
+	return func(b *di.Config) {
+		b.CompilerConfig.ReportUnused = true
+		b.CompilerConfig.UnusedReport = w
+	}
+}
+
+// WarningHandler sets a handler that is called for each warning emitted during the build.
+func WarningHandler(h di.WarningHandler) BuilderOption {
+	return func(b *di.Config) {
+		b.WarningHandler = h
+	}
+}
+
+// LogWarnings makes the builder log all warnings emitted during the build with the given logger.
+func LogWarnings(logger *slog.Logger) BuilderOption {
+	return WarningHandler(func(w di.Warning) {
+		logger.Warn(w.Message, slog.String("pass", w.Pass))
+	})
+}
+
+// Interceptors registers interceptors that observe the calls made by the container:
+// service instantiations, method calls and function calls.
+// See di.NewSlogInterceptor and di.NewTimingReport for the built-in interceptors.
+func Interceptors(interceptors ...di.Interceptor) BuilderOption {
+	return func(b *di.Config) {
+		b.Interceptors = append(b.Interceptors, interceptors...)
+	}
+}
@@ -0,0 +1,168 @@
+// Command godi-gen generates reflection-free Go code from the godi container defined in a package.
+//
+// The package must declare a variable (Wiring by default) that holds a *godi.Builder:
+//
+//	var Wiring = di.New().Services(...)
+//
+// godi-gen compiles the container, which validates its definitions, and writes a file to the package
+// with a container type, that constructs the same services with direct calls. See di.Generate for details.
+// The generated file is excluded from the build of godi-gen itself (by the godigen build tag),
+// so a stale generated file never prevents its regeneration.
+//
+// Usage:
+//
+//	godi-gen [flags] [package directory]
+//
+// It's typically run by go generate:
+//
+//	//go:generate go run github.com/michalkurzeja/godi/v2/cmd/godi-gen -o wiring_gen.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+
+	"github.com/michalkurzeja/godi/v2/internal/errorsx"
+)
+
+// buildTag excludes the generated files from the build of the generator program.
+const buildTag = "godigen"
+
+func main() {
+	varName := flag.String("var", "Wiring", "name of the variable that holds the *godi.Builder")
+	typeName := flag.String("type", "Container", "name of the generated container type")
+	output := flag.String("o", "godi_gen.go", "name of the generated file, relative to the package directory")
+	flag.Usage = func() {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "Usage: godi-gen [flags] [package directory]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+
+	if err := run(dir, *varName, *typeName, *output); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "godi-gen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+type pkg struct {
+	Dir        string
+	ImportPath string
+	Name       string
+}
+
+func run(dir, varName, typeName, output string) error {
+	if !token.IsExported(varName) {
+		return fmt.Errorf("variable %s must be exported", varName)
+	}
+	if !token.IsIdentifier(typeName) {
+		return fmt.Errorf("invalid type name %q", typeName)
+	}
+
+	p, err := loadPackage(dir)
+	if err != nil {
+		return err
+	}
+	if p.Name == "main" {
+		return errors.New("the wiring cannot be loaded from a main package, move it to another package")
+	}
+
+	// The generator program is created in the package directory, so that it belongs to the same module.
+	tmpDir, err := os.MkdirTemp(p.Dir, "_godigen")
+	if err != nil {
+		return errorsx.Wrap(err, "failed to create the generator program")
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	var src bytes.Buffer
+	err = generatorTmpl.Execute(&src, map[string]string{
+		"ImportPath": p.ImportPath,
+		"Name":       p.Name,
+		"Var":        varName,
+		"Type":       typeName,
+		"Output":     filepath.Join(p.Dir, output),
+		"BuildTag":   buildTag,
+	})
+	if err != nil {
+		return errorsx.Wrap(err, "failed to create the generator program")
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), src.Bytes(), 0o600); err != nil {
+		return errorsx.Wrap(err, "failed to create the generator program")
+	}
+
+	cmd := exec.Command("go", "run", "-tags", buildTag, "./"+filepath.Base(tmpDir))
+	cmd.Dir = p.Dir
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errorsx.Wrap(err, "failed to generate the container")
+	}
+	return nil
+}
+
+func loadPackage(dir string) (pkg, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("go", "list", "-tags", buildTag, "-json", ".")
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return pkg{}, fmt.Errorf("failed to load the package in %s: %w: %s", dir, err, stderr.String())
+	}
+
+	var p pkg
+	if err := json.Unmarshal(out, &p); err != nil {
+		return pkg{}, errorsx.Wrap(err, "failed to load the package")
+	}
+	return p, nil
+}
+
+var generatorTmpl = template.Must(template.New("generator").Parse(`//go:build {{.BuildTag}}
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/michalkurzeja/godi/v2/digen"
+
+	target {{printf "%q" .ImportPath}}
+)
+
+func main() {
+	var buf bytes.Buffer
+	err := digen.Generate(target.{{.Var}}, &buf, digen.Config{
+		PkgName:  {{printf "%q" .Name}},
+		PkgPath:  {{printf "%q" .ImportPath}},
+		TypeName: {{printf "%q" .Type}},
+		Source:   {{printf "%q" .Var}},
+
+		BuildConstraint: "!{{.BuildTag}}",
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile({{printf "%q" .Output}}, buf.Bytes(), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`))
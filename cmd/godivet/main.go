@@ -0,0 +1,20 @@
+// Command godivet reports invalid godi definitions (see the divet package).
+//
+// Usage:
+//
+//	godivet [flags] [packages]
+//
+// It can also be run by go vet:
+//
+//	go vet -vettool=$(which godivet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/michalkurzeja/godi/v2/divet"
+)
+
+func main() {
+	singlechecker.Main(divet.Analyzer)
+}
@@ -0,0 +1,137 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/michalkurzeja/godi/v2/di"
+	"github.com/michalkurzeja/godi/v2/internal/errorsx"
+	"github.com/michalkurzeja/godi/v2/internal/util"
+)
+
+type collectConfig struct {
+	required bool
+	cmp      func(a, b *di.ServiceDefinition) int
+}
+
+type CollectOption func(*collectConfig)
+
+// Required makes the collecting pass fail if no service has the label.
+func Required() CollectOption {
+	return func(c *collectConfig) {
+		c.required = true
+	}
+}
+
+// OrderBy sets the order of the collected services.
+// By default, they are ordered by priority and then by the order of registration (see ServiceDefinitionBuilder.Priority).
+func OrderBy(cmp func(a, b *di.ServiceDefinition) int) CollectOption {
+	return func(c *collectConfig) {
+		c.cmp = cmp
+	}
+}
+
+// CollectLabelled returns a compiler pass that injects all services with the given label,
+// visible from the scope of the target service (into), into that service. The target itself is skipped.
+// The viaMethod decides how the services are injected:
+//   - a method expression (e.g. (*Registry).Add) that accepts a slice (or is variadic) adds a method call
+//     with all the services, or appends them to the call of that method if the target already has one,
+//   - a slot index (e.g. uint(0)) appends the services to the slice argument of the factory at that slot.
+//
+// The pass runs in the automation stage, before the autowiring.
+func CollectLabelled(label Label, into *SvcReference, viaMethod any, opts ...CollectOption) *di.CompilerPass {
+	var conf collectConfig
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	name := fmt.Sprintf("collect labelled (%s)", label)
+	return di.NewCompilerPass(name, di.Automation, di.CompilerOpFunc(func(builder *di.ContainerBuilder) error {
+		if into.IsEmpty() {
+			return fmt.Errorf("cannot collect services labelled %s: empty target reference", label)
+		}
+		builder.UseLabels(label)
+		// The target is looked up by ID, as the pass can run on a clone of the builder (see Builder.Clone).
+		target, ok := builder.ServiceDefinition(into.SvcID())
+		if !ok {
+			return fmt.Errorf("cannot collect services labelled %s into %s: service not found", label, into)
+		}
+
+		defs := slices.DeleteFunc(target.Scope().GetServiceDefinitionsByLabelInChain(label), func(def *di.ServiceDefinition) bool {
+			return def == target
+		})
+		if len(defs) == 0 {
+			if conf.required {
+				return fmt.Errorf("no services labelled %s found for %s", label, target)
+			}
+			return nil
+		}
+		if conf.cmp != nil {
+			slices.SortStableFunc(defs, conf.cmp)
+		}
+
+		refs := make([]di.Arg, 0, len(defs))
+		for _, def := range defs {
+			ref, err := di.NewRefArg(def)
+			if err != nil {
+				return err
+			}
+			refs = append(refs, ref)
+		}
+
+		err := collectInto(target, viaMethod, refs)
+		if err != nil {
+			return errorsx.Wrapf(err, "failed to collect services labelled %s into %s", label, target)
+		}
+		return nil
+	})).Before("autowiring")
+}
+
+func collectInto(target *di.ServiceDefinition, via any, refs []di.Arg) error {
+	switch v := via.(type) {
+	case int:
+		if v < 0 {
+			return fmt.Errorf("invalid factory slot %d", v)
+		}
+		return collectIntoSlot(target, uint(v), refs)
+	case uint:
+		return collectIntoSlot(target, v, refs)
+	}
+
+	method := reflect.ValueOf(via)
+	if method.Kind() != reflect.Func {
+		return fmt.Errorf("expected a method or a factory slot, got %T", via)
+	}
+	if method.Type().NumIn() != 2 || method.Type().In(1).Kind() != reflect.Slice {
+		return fmt.Errorf("method %s must accept a single slice argument", util.FuncName(method))
+	}
+
+	// Method calls are unique by name, so the services are appended to the existing call if there is one.
+	for _, m := range target.MethodCalls() {
+		if m.Name() == util.FuncName(method) {
+			return m.AddArgs(refs...)
+		}
+	}
+
+	receiver, err := di.NewRefArg(target)
+	if err != nil {
+		return err
+	}
+	m, err := di.NewMethod(via, receiver, refs...)
+	if err != nil {
+		return err
+	}
+	target.AddMethodCalls(m)
+	return nil
+}
+
+func collectIntoSlot(target *di.ServiceDefinition, slot uint, refs []di.Arg) error {
+	for _, ref := range refs {
+		err := target.Factory().Args().AppendSlot(di.NewSlottedArg(ref, slot))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
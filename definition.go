@@ -3,14 +3,22 @@ package di
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
+	"time"
 
 	"github.com/michalkurzeja/godi/v2/di"
 	"github.com/michalkurzeja/godi/v2/internal/errorsx"
+	"github.com/michalkurzeja/godi/v2/internal/util"
 )
 
 type ID = di.ID
 type Label = di.Label
+type Attrs = di.Attrs
+
+// EntryPoint labels services that are used directly (e.g. retrieved from the container),
+// rather than as dependencies of other services. See ReportUnused.
+const EntryPoint = di.EntryPoint
 
 type SvcReference struct {
 	def *di.ServiceDefinition
@@ -34,6 +42,37 @@ func (r SvcReference) String() string {
 	return r.def.String()
 }
 
+// TypedRef is a reference to a service, that carries the type of the service.
+// Once bound (see ServiceDefinitionBuilder.Bind), the service is checked to be assignable to T.
+// It can be used to retrieve the service without specifying its type (see Get)
+// and as an argument (see Arg), that is known to be of type T before the service is built.
+type TypedRef[T any] struct {
+	SvcReference
+}
+
+// SvcBinder is a reference that can be bound to a service definition: *SvcReference or *TypedRef[T].
+type SvcBinder interface {
+	// bind binds the reference to the definition and returns the type the service must be assignable to, if any.
+	bind(def *di.ServiceDefinition) reflect.Type
+}
+
+func (r *SvcReference) bind(def *di.ServiceDefinition) reflect.Type {
+	r.def = def
+	return nil
+}
+
+func (r *TypedRef[T]) bind(def *di.ServiceDefinition) reflect.Type {
+	r.def = def
+	return reflect.TypeFor[T]()
+}
+
+// Arg returns an argument builder for the reference. The argument is of type T.
+func (r *TypedRef[T]) Arg() *ArgBuilder {
+	return &ArgBuilder{newArg: func() (di.Arg, error) {
+		return di.NewTypedRefArg(r.def, reflect.TypeFor[T]())
+	}}
+}
+
 type FuncReference struct {
 	def *di.FunctionDefinition
 }
@@ -66,6 +105,7 @@ type ServiceDefinitionBuilder struct {
 	factory  *funcBuilder
 	methods  []*funcBuilder
 	children []*ServiceDefinitionBuilder
+	refTypes []reflect.Type
 
 	factoryParsed bool
 }
@@ -83,8 +123,24 @@ func SvcVal[T any](svc T) *ServiceDefinitionBuilder {
 	return Svc(func() T { return svc })
 }
 
-func (b *ServiceDefinitionBuilder) Bind(ref *SvcReference) *ServiceDefinitionBuilder {
-	ref.def = b.def
+// Bind binds the service to a reference. A typed reference (TypedRef) requires the service to be assignable to its type.
+func (b *ServiceDefinitionBuilder) Bind(ref SvcBinder) *ServiceDefinitionBuilder {
+	if typ := ref.bind(b.def); typ != nil {
+		b.refTypes = append(b.refTypes, typ)
+	}
+	return b
+}
+
+// Name gives the service a unique name, that can be used to retrieve it with SvcByName or reference it with Named.
+// Names must be unique across a scope chain.
+func (b *ServiceDefinitionBuilder) Name(name string) *ServiceDefinitionBuilder {
+	b.def.SetName(name)
+	return b
+}
+
+// Key sets the key of the service in injected maps (see MapOf). If no key is set, the name of the service is used.
+func (b *ServiceDefinitionBuilder) Key(key string) *ServiceDefinitionBuilder {
+	b.def.SetKey(key)
 	return b
 }
 
@@ -98,6 +154,24 @@ func (b *ServiceDefinitionBuilder) Labels(labels ...Label) *ServiceDefinitionBui
 	return b
 }
 
+// Tag adds a tag with the given attributes. The name of the tag is also added as a label.
+// A definition can have multiple tags with the same name.
+func (b *ServiceDefinitionBuilder) Tag(name Label, attrs ...Attrs) *ServiceDefinitionBuilder {
+	merged := make(Attrs)
+	for _, a := range attrs {
+		maps.Copy(merged, a)
+	}
+	b.def.AddTags(di.NewTag(name, merged))
+	return b
+}
+
+// Priority sets the priority of the service. Injected slices and collections of services
+// are ordered by priority, the highest first, and then by the order of registration.
+func (b *ServiceDefinitionBuilder) Priority(priority int) *ServiceDefinitionBuilder {
+	b.def.SetPriority(priority)
+	return b
+}
+
 func (b *ServiceDefinitionBuilder) Lazy() *ServiceDefinitionBuilder {
 	b.def.SetLazy(true)
 	return b
@@ -128,6 +202,24 @@ func (b *ServiceDefinitionBuilder) NotAutowired() *ServiceDefinitionBuilder {
 	return b
 }
 
+// RetryOnError makes the container retry a failed instantiation of the service up to n times,
+// waiting backoff before the first retry and twice as long before each subsequent one.
+func (b *ServiceDefinitionBuilder) RetryOnError(n int, backoff time.Duration) *ServiceDefinitionBuilder {
+	policy := b.def.ErrorPolicy()
+	policy.Retries, policy.Backoff = n, backoff
+	b.def.SetErrorPolicy(policy)
+	return b
+}
+
+// CacheErrors makes all subsequent resolutions of a shared service fail with the error of its failed instantiation
+// (after the retries, see RetryOnError), instead of instantiating it again. Refreshing the service clears the error.
+func (b *ServiceDefinitionBuilder) CacheErrors() *ServiceDefinitionBuilder {
+	policy := b.def.ErrorPolicy()
+	policy.CacheErrors = true
+	b.def.SetErrorPolicy(policy)
+	return b
+}
+
 func (b *ServiceDefinitionBuilder) Children(services ...*ServiceDefinitionBuilder) *ServiceDefinitionBuilder {
 	b.children = append(b.children, services...)
 	return b
@@ -148,6 +240,11 @@ func (b *ServiceDefinitionBuilder) ParseFactory() (joinedErrs error) {
 		joinedErrs = errors.Join(joinedErrs, errorsx.Wrap(err, "failed to build factory"))
 	} else {
 		b.def.SetFactory(f)
+		for _, typ := range b.refTypes {
+			if !b.def.Type().AssignableTo(typ) {
+				joinedErrs = errors.Join(joinedErrs, fmt.Errorf("reference of type %s cannot be bound to service of type %s", util.Signature(typ), util.Signature(b.def.Type())))
+			}
+		}
 	}
 
 	for _, child := range b.children {
@@ -260,6 +357,17 @@ func (b *FunctionDefinitionBuilder) Labels(labels ...Label) *FunctionDefinitionB
 	return b
 }
 
+// Tag adds a tag with the given attributes. The name of the tag is also added as a label.
+// A definition can have multiple tags with the same name.
+func (b *FunctionDefinitionBuilder) Tag(name Label, attrs ...Attrs) *FunctionDefinitionBuilder {
+	merged := make(Attrs)
+	for _, a := range attrs {
+		maps.Copy(merged, a)
+	}
+	b.def.AddTags(di.NewTag(name, merged))
+	return b
+}
+
 func (b *FunctionDefinitionBuilder) Lazy() *FunctionDefinitionBuilder {
 	b.def.SetLazy(true)
 	return b
@@ -1,9 +1,12 @@
 package di
 
 import (
+	"context"
+	"expvar"
 	"fmt"
 	"io"
 	"reflect"
+	"sync"
 
 	"github.com/michalkurzeja/godi/v2/di"
 	"github.com/michalkurzeja/godi/v2/internal/util"
@@ -12,11 +15,14 @@ import (
 type Container interface {
 	HasService(id di.ID) bool
 	GetService(id di.ID) (any, error)
+	GetServiceByName(name string) (any, error)
 	GetServices(ids ...di.ID) (svcs []any, err error)
 	GetServicesIDsByType(typ reflect.Type) []ID
 	GetServicesByType(typ reflect.Type) ([]any, error)
+	GetServicesByTypeContext(ctx context.Context, typ reflect.Type) ([]any, error)
 	GetServicesIDsByLabel(label Label) []ID
 	GetServicesByLabel(label di.Label) ([]any, error)
+	GetTaggedServices(tag di.Label) ([]di.TaggedService, error)
 	HasFunction(id di.ID) bool
 	ExecuteFunction(id di.ID) ([]any, error)
 	ExecuteFunctions(ids ...di.ID) (results [][]any, err error)
@@ -24,7 +30,27 @@ type Container interface {
 	ExecuteFunctionsByType(typ reflect.Type) ([][]any, error)
 	GetFunctionsIDsByLabel(label Label) []ID
 	ExecuteFunctionsByLabel(label di.Label) ([][]any, error)
+	ServiceDefinitions() []di.ServiceDefinitionView
+	FunctionDefinitions() []di.FunctionDefinitionView
+	Refresh(ids ...di.ID) error
+	OnRefresh(handlers ...di.RefreshHandler)
 	Print(w io.Writer)
+	Stats() map[ID]DefinitionStats
+}
+
+type DefinitionStats = di.DefinitionStats
+
+// ServiceDefinitionView and FunctionDefinitionView are read-only views of the definitions of a built container.
+// The definitions themselves are frozen once the container is built.
+type (
+	ServiceDefinitionView  = di.ServiceDefinitionView
+	FunctionDefinitionView = di.FunctionDefinitionView
+)
+
+// PublishStats exposes the runtime stats of the container as an expvar variable with the given name.
+// Like expvar.Publish, it panics if the name is already taken.
+func PublishStats(name string, c Container) {
+	expvar.Publish(name, di.NewStatsVar(c))
 }
 
 // SvcByRef returns a service from the container by its reference.
@@ -42,11 +68,34 @@ func SvcByRef[T any](c Container, ref SvcReference) (T, error) {
 	return castTo[T](svc)
 }
 
+// Get returns the service bound to the typed reference.
+func Get[T any](c Container, ref TypedRef[T]) (T, error) {
+	return SvcByRef[T](c, ref.SvcReference)
+}
+
+// ServiceNameNotFoundError is returned by SvcByName if no service has the given name.
+type ServiceNameNotFoundError = di.ServiceNameNotFoundError
+
+// SvcByName returns a service from the container by its name.
+// If no service has the name, the error is a *ServiceNameNotFoundError.
+func SvcByName[T any](c Container, name string) (T, error) {
+	svc, err := c.GetServiceByName(name)
+	if err != nil {
+		return util.Zero[T](), err
+	}
+	if svc == nil {
+		return util.Zero[T](), nil // The factory returned a nil interface.
+	}
+	return castTo[T](svc)
+}
+
 // SvcByType returns a service from the container by its type.
 func SvcByType[T any](c Container) (T, error) {
-	typ := reflect.TypeFor[T]()
+	return svcByType[T](c.GetServicesByType(reflect.TypeFor[T]()))
+}
 
-	svcs, err := c.GetServicesByType(typ)
+func svcByType[T any](svcs []any, err error) (T, error) {
+	typ := reflect.TypeFor[T]()
 	if err != nil {
 		return util.Zero[T](), err
 	}
@@ -61,6 +110,42 @@ func SvcByType[T any](c Container) (T, error) {
 	return castTo[T](svcs[0])
 }
 
+// Result is the outcome of an asynchronous resolution of a service.
+type Result[T any] struct {
+	Svc T
+	Err error
+}
+
+// SvcByTypeAsync resolves a service by its type (see SvcByType) in a separate goroutine.
+// The returned channel receives a single result and is closed. If the context is done first,
+// the result holds the context's error, while the resolution continues in the background.
+// Concurrent resolutions of a shared service share a single instantiation.
+// The calls made by the resolution carry the context (see di.Call), e.g. to attach their spans to the trace of the caller.
+func SvcByTypeAsync[T any](ctx context.Context, c Container) <-chan Result[T] {
+	ch := make(chan Result[T], 1)
+	var once sync.Once
+	send := func(res Result[T]) {
+		once.Do(func() {
+			ch <- res
+			close(ch)
+		})
+	}
+
+	if err := ctx.Err(); err != nil {
+		send(Result[T]{Err: err})
+		return ch
+	}
+
+	stop := context.AfterFunc(ctx, func() { send(Result[T]{Err: ctx.Err()}) })
+	go func() {
+		defer stop()
+		svc, err := svcByType[T](c.GetServicesByTypeContext(ctx, reflect.TypeFor[T]()))
+		send(Result[T]{Svc: svc, Err: err})
+	}()
+
+	return ch
+}
+
 // SvcsByType returns all services from the container by their type.
 func SvcsByType[T any](c Container) ([]T, error) {
 	typ := reflect.TypeFor[T]()
@@ -100,6 +185,31 @@ func SvcsByLabel[T any](c Container, label Label) ([]T, error) {
 	return castSliceTo[T](ids)
 }
 
+// Tagged is a service together with the attributes of one of its tags.
+type Tagged[T any] struct {
+	Svc   T
+	Attrs Attrs
+}
+
+// SvcsByTag returns all services from the container with the given tag, each with the attributes of the tag.
+// A service tagged multiple times with the same tag is returned once per tag.
+func SvcsByTag[T any](c Container, tag Label) ([]Tagged[T], error) {
+	tagged, err := c.GetTaggedServices(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	svcs := make([]Tagged[T], len(tagged))
+	for i, t := range tagged {
+		svc, err := castTo[T](t.Svc)
+		if err != nil {
+			return nil, err
+		}
+		svcs[i] = Tagged[T]{Svc: svc, Attrs: t.Attrs}
+	}
+	return svcs, nil
+}
+
 // ExecByRef executes a function by its reference.
 func ExecByRef(c Container, ref FuncReference) ([]any, error) {
 	if ref.IsEmpty() {
@@ -146,6 +256,29 @@ func ExecAllByLabel(c Container, label Label) ([][]any, error) {
 	return c.ExecuteFunctionsByLabel(label)
 }
 
+type RefreshEvent = di.RefreshEvent
+
+// RefreshByRef drops the cached instance of the referenced service and of all services that depend on it,
+// so that they are instantiated again on the next resolution. See di.Container.Refresh.
+func RefreshByRef(c Container, ref SvcReference) error {
+	if ref.IsEmpty() {
+		return fmt.Errorf("cannot refresh service: empty reference")
+	}
+	return c.Refresh(ref.SvcID())
+}
+
+// RefreshByType drops the cached instances of the services of type T and of all services that depend on them,
+// so that they are instantiated again on the next resolution. See di.Container.Refresh.
+func RefreshByType[T any](c Container) error {
+	typ := reflect.TypeFor[T]()
+
+	ids := c.GetServicesIDsByType(typ)
+	if len(ids) == 0 {
+		return fmt.Errorf("service of type %s not found", util.Signature(typ))
+	}
+	return c.Refresh(ids...)
+}
+
 func castSliceTo[T any](svcsAny []any) ([]T, error) {
 	svcs := make([]T, 0, len(svcsAny))
 	for _, svcAny := range svcsAny {
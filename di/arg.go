@@ -37,6 +37,7 @@ func (a *literalArg) Type() reflect.Type {
 
 type refArg struct {
 	def *ServiceDefinition
+	typ reflect.Type // Nil unless the reference is typed.
 }
 
 func NewRefArg(def *ServiceDefinition) (Arg, error) {
@@ -46,11 +47,24 @@ func NewRefArg(def *ServiceDefinition) (Arg, error) {
 	return &refArg{def: def}, nil
 }
 
+// NewTypedRefArg returns a reference to a service, that is known to be assignable to the given type.
+// The argument is of that type, so it can be slotted before the factory of the service is known.
+// The validation checks that the service is indeed assignable to the type.
+func NewTypedRefArg(def *ServiceDefinition, typ reflect.Type) (Arg, error) {
+	if def == nil {
+		return nil, fmt.Errorf("ref arg requires a non-nil service definition")
+	}
+	return &refArg{def: def, typ: typ}, nil
+}
+
 func (a *refArg) String() string {
 	return a.def.String()
 }
 
 func (a *refArg) Type() reflect.Type {
+	if a.typ != nil {
+		return a.typ
+	}
 	return a.def.Type()
 }
 
@@ -95,6 +109,55 @@ func (a *labelArg) Type() reflect.Type {
 	return a.typ
 }
 
+type nameArg struct {
+	name string
+	typ  reflect.Type
+}
+
+func NewNameArg(name string, typ reflect.Type) Arg {
+	return &nameArg{name: name, typ: typ}
+}
+
+func (a *nameArg) String() string {
+	return fmt.Sprintf("%q", a.name)
+}
+
+func (a *nameArg) Type() reflect.Type {
+	return a.typ
+}
+
+type mapArg struct {
+	typ   reflect.Type
+	keyFn func(key string) any
+	label Label
+}
+
+// NewMapArg returns an argument that resolves to a map of services, keyed by their Key.
+// The map contains all services with a non-empty key that are assignable to the map's element type
+// (and have the given label, if it's not empty). Services without a key are skipped.
+// The keyFn converts a service key to a map key. If it's nil, the key is converted to the
+// map's key type directly, which requires the map's key type to be a string.
+func NewMapArg(typ reflect.Type, keyFn func(key string) any, label Label) (Arg, error) {
+	if typ.Kind() != reflect.Map {
+		return nil, fmt.Errorf("map arg requires a map type, got %s", util.Signature(typ))
+	}
+	if keyFn == nil && typ.Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("map arg of type %s requires a key function", util.Signature(typ))
+	}
+	return &mapArg{typ: typ, keyFn: keyFn, label: label}, nil
+}
+
+func (a *mapArg) String() string {
+	if a.label != "" {
+		return fmt.Sprintf("%s (%s)", util.Signature(a.typ), a.label)
+	}
+	return util.Signature(a.typ)
+}
+
+func (a *mapArg) Type() reflect.Type {
+	return a.typ
+}
+
 type flexibleSliceArg struct {
 	elemType   reflect.Type
 	allowEmpty bool
@@ -142,6 +205,22 @@ func (c *compoundArg) Type() reflect.Type {
 	return c.typ
 }
 
+type reversedArg struct {
+	Arg
+}
+
+// NewReversedArg returns an argument that resolves to the slice that the given argument resolves to, in reverse order.
+func NewReversedArg(arg Arg) (Arg, error) {
+	if _, ok := arg.(*compoundArg); !ok && arg.Type().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot reverse argument %s: not a slice", arg)
+	}
+	return &reversedArg{Arg: arg}, nil
+}
+
+func (a *reversedArg) String() string {
+	return fmt.Sprintf("%s (reversed)", a.Arg)
+}
+
 func NewSlottedArg(arg Arg, slot uint) *SlottedArg {
 	return &SlottedArg{Arg: arg, slot: slot}
 }
@@ -169,6 +248,8 @@ type Slot struct {
 	typ      reflect.Type
 	i        uint
 	variadic bool
+
+	frozen bool
 }
 
 func NewSlot(typ reflect.Type, i uint, variadic bool) *Slot {
@@ -220,6 +301,7 @@ func (s *Slot) Fill(arg Arg) error {
 }
 
 func (s *Slot) Set(arg Arg) error {
+	s.mustNotBeFrozen()
 	if !s.SettableBy(arg) {
 		return fmt.Errorf("argument %s cannot be assigned to slot %d", arg.Type(), s.i)
 	}
@@ -229,6 +311,7 @@ func (s *Slot) Set(arg Arg) error {
 }
 
 func (s *Slot) Append(args ...Arg) error {
+	s.mustNotBeFrozen()
 	if !s.IsSlice() {
 		return fmt.Errorf("cannot add args to slot %d: slot not a slice", s.i)
 	}
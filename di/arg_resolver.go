@@ -1,9 +1,11 @@
 package di
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 
 	"github.com/samber/lo"
 
@@ -25,13 +27,41 @@ func ResolveArgIDs(scope *Scope, arg Arg) []ID {
 	return resolver.ResolveIDs(scope, arg)
 }
 
+// ResolveServiceDependencyIDs returns the IDs of all services that the given service depends on,
+// either through the arguments of its factory or of its method calls.
+func ResolveServiceDependencyIDs(def *ServiceDefinition) []ID {
+	ids := resolveArgListIDs(def.EffectiveScope(), def.Factory().Args())
+	for _, method := range def.MethodCalls() {
+		ids = append(ids, resolveArgListIDs(def.EffectiveScope(), method.Args())...)
+	}
+	return lo.Without(lo.Uniq(ids), def.ID()) // Method receivers reference the service itself.
+}
+
+// ResolveFunctionDependencyIDs returns the IDs of all services that the given function depends on.
+func ResolveFunctionDependencyIDs(def *FunctionDefinition) []ID {
+	return lo.Uniq(resolveArgListIDs(def.EffectiveScope(), def.Func().Args()))
+}
+
+func resolveArgListIDs(scope *Scope, args *ArgList) []ID {
+	var ids []ID
+	for _, slot := range args.Slots() {
+		if arg := slot.Arg(); arg != nil {
+			ids = append(ids, ResolveArgIDs(scope, arg)...)
+		}
+	}
+	return ids
+}
+
 type ArgResolver struct {
 	literalArgResolver       *literalArgResolver
 	refArgResolver           *refArgResolver
 	typeArgResolver          *typeArgResolver
 	labelArgResolver         *labelArgResolver
+	nameArgResolver          *nameArgResolver
 	flexibleSliceArgResolver *flexibleSliceArgResolver
 	compoundArgResolver      *compoundArgResolver
+	mapArgResolver           *mapArgResolver
+	reversedArgResolver      *reversedArgResolver
 }
 
 func NewArgResolver() *ArgResolver {
@@ -40,8 +70,11 @@ func NewArgResolver() *ArgResolver {
 	r.refArgResolver = &refArgResolver{}
 	r.typeArgResolver = &typeArgResolver{resolver: r}
 	r.labelArgResolver = &labelArgResolver{resolver: r}
+	r.nameArgResolver = &nameArgResolver{}
 	r.flexibleSliceArgResolver = &flexibleSliceArgResolver{resolver: r}
 	r.compoundArgResolver = &compoundArgResolver{resolver: r}
+	r.mapArgResolver = &mapArgResolver{resolver: r}
+	r.reversedArgResolver = &reversedArgResolver{resolver: r}
 	return r
 }
 
@@ -55,29 +88,45 @@ func (r *ArgResolver) Validate(scope *Scope, arg Arg) error {
 		return r.typeArgResolver.Validate(scope, a)
 	case *labelArg:
 		return r.labelArgResolver.Validate(scope, a)
+	case *nameArg:
+		return r.nameArgResolver.Validate(scope, a)
 	case *flexibleSliceArg:
 		return r.flexibleSliceArgResolver.Validate(scope, a)
 	case *compoundArg:
 		return r.compoundArgResolver.Validate(scope, a)
+	case *mapArg:
+		return r.mapArgResolver.Validate(scope, a)
+	case *reversedArg:
+		return r.reversedArgResolver.Validate(scope, a)
 	default:
 		return fmt.Errorf("unsupported arg type %T", arg)
 	}
 }
 
 func (r *ArgResolver) Resolve(scope *Scope, arg Arg) (any, error) {
+	return r.resolve(newResolution(context.Background()), scope, arg)
+}
+
+func (r *ArgResolver) resolve(res resolution, scope *Scope, arg Arg) (any, error) {
 	switch a := arg.(type) {
 	case *literalArg:
-		return r.literalArgResolver.Resolve(scope, a)
+		return r.literalArgResolver.Resolve(res, scope, a)
 	case *refArg:
-		return r.refArgResolver.Resolve(scope, a)
+		return r.refArgResolver.Resolve(res, scope, a)
 	case *typeArg:
-		return r.typeArgResolver.Resolve(scope, a)
+		return r.typeArgResolver.Resolve(res, scope, a)
 	case *labelArg:
-		return r.labelArgResolver.Resolve(scope, a)
+		return r.labelArgResolver.Resolve(res, scope, a)
+	case *nameArg:
+		return r.nameArgResolver.Resolve(res, scope, a)
 	case *flexibleSliceArg:
-		return r.flexibleSliceArgResolver.Resolve(scope, a)
+		return r.flexibleSliceArgResolver.Resolve(res, scope, a)
 	case *compoundArg:
-		return r.compoundArgResolver.Resolve(scope, a)
+		return r.compoundArgResolver.Resolve(res, scope, a)
+	case *mapArg:
+		return r.mapArgResolver.Resolve(res, scope, a)
+	case *reversedArg:
+		return r.reversedArgResolver.Resolve(res, scope, a)
 	default:
 		return reflect.Value{}, fmt.Errorf("unsupported arg type %T", arg)
 	}
@@ -93,10 +142,16 @@ func (r *ArgResolver) ResolveIDs(scope *Scope, arg Arg) []ID {
 		return r.typeArgResolver.ResolveIDs(scope, a)
 	case *labelArg:
 		return r.labelArgResolver.ResolveIDs(scope, a)
+	case *nameArg:
+		return r.nameArgResolver.ResolveIDs(scope, a)
 	case *flexibleSliceArg:
 		return r.flexibleSliceArgResolver.ResolveIDs(scope, a)
 	case *compoundArg:
 		return r.compoundArgResolver.ResolveIDs(scope, a)
+	case *mapArg:
+		return r.mapArgResolver.ResolveIDs(scope, a)
+	case *reversedArg:
+		return r.reversedArgResolver.ResolveIDs(scope, a)
 	default:
 		return nil
 	}
@@ -108,7 +163,7 @@ func (r *literalArgResolver) Validate(_ *Scope, _ *literalArg) error {
 	return nil
 }
 
-func (r *literalArgResolver) Resolve(_ *Scope, a *literalArg) (any, error) {
+func (r *literalArgResolver) Resolve(_ resolution, _ *Scope, a *literalArg) (any, error) {
 	return a.v, nil
 }
 
@@ -122,11 +177,14 @@ func (r *refArgResolver) Validate(scope *Scope, a *refArg) error {
 	if !scope.HasServiceInChain(a.def.ID()) {
 		return fmt.Errorf("service %s not found", a.def.ID())
 	}
+	if a.typ != nil && !a.def.Type().AssignableTo(a.typ) {
+		return fmt.Errorf("service %s should be assignable to type %s", a.def, util.Signature(a.typ))
+	}
 	return nil
 }
 
-func (r *refArgResolver) Resolve(scope *Scope, a *refArg) (any, error) {
-	v, err := scope.GetServiceInChain(a.def.ID())
+func (r *refArgResolver) Resolve(res resolution, scope *Scope, a *refArg) (any, error) {
+	v, err := scope.getServiceInChain(res, a.def.ID())
 	if err != nil {
 		return nil, errorsx.Wrap(err, "failed to resolve ID arg")
 	}
@@ -156,11 +214,11 @@ func (r *typeArgResolver) Validate(scope *Scope, a *typeArg) error {
 	return nil
 }
 
-func (r *typeArgResolver) Resolve(scope *Scope, a *typeArg) (any, error) {
+func (r *typeArgResolver) Resolve(res resolution, scope *Scope, a *typeArg) (any, error) {
 	if boundTo, ok := scope.GetBoundArgInChain(a.typ); ok {
-		return r.resolver.Resolve(scope, boundTo)
+		return r.resolver.resolve(res, scope, boundTo)
 	}
-	vals, err := scope.GetServicesByTypeInChain(a.typ)
+	vals, err := scope.getServicesByTypeInChain(res, a.typ)
 	if err != nil {
 		return nil, errorsx.Wrap(err, "failed to resolve type arg")
 	}
@@ -197,8 +255,8 @@ func (r *labelArgResolver) Validate(scope *Scope, a *labelArg) error {
 	return nil
 }
 
-func (r *labelArgResolver) Resolve(scope *Scope, a *labelArg) (any, error) {
-	vals, err := scope.GetServicesByLabelInChain(a.label)
+func (r *labelArgResolver) Resolve(res resolution, scope *Scope, a *labelArg) (any, error) {
+	vals, err := scope.getServicesByLabelInChain(res, a.label)
 	if err != nil {
 		return nil, errorsx.Wrap(err, "failed to resolve type arg")
 	}
@@ -223,6 +281,36 @@ func (r *labelArgResolver) ResolveIDs(scope *Scope, a *labelArg) []ID {
 	return scope.GetServicesIDsByLabelInChain(a.label)
 }
 
+type nameArgResolver struct{}
+
+func (r *nameArgResolver) Validate(scope *Scope, a *nameArg) error {
+	id, ok := scope.GetServiceIDByNameInChain(a.name)
+	if !ok {
+		return fmt.Errorf("no service found with name %q", a.name)
+	}
+	def, _ := scope.GetServiceDefinitionInChain(id)
+	if !def.Type().AssignableTo(a.typ) {
+		return fmt.Errorf("service named %q should be assignable to type %s, got %s", a.name, util.Signature(a.typ), util.Signature(def.Type()))
+	}
+	return nil
+}
+
+func (r *nameArgResolver) Resolve(res resolution, scope *Scope, a *nameArg) (any, error) {
+	v, err := scope.getServiceByNameInChain(res, a.name)
+	if err != nil {
+		return nil, errorsx.Wrap(err, "failed to resolve name arg")
+	}
+	return v, nil
+}
+
+func (r *nameArgResolver) ResolveIDs(scope *Scope, a *nameArg) []ID {
+	id, ok := scope.GetServiceIDByNameInChain(a.name)
+	if !ok {
+		return nil
+	}
+	return []ID{id}
+}
+
 type flexibleSliceArgResolver struct{ resolver *ArgResolver }
 
 func (r *flexibleSliceArgResolver) Validate(scope *Scope, a *flexibleSliceArg) error {
@@ -254,12 +342,12 @@ func (r *flexibleSliceArgResolver) Validate(scope *Scope, a *flexibleSliceArg) e
 	return fmt.Errorf("no services found for type %s", util.Signature(a.Type()))
 }
 
-func (r *flexibleSliceArgResolver) Resolve(scope *Scope, a *flexibleSliceArg) (any, error) {
+func (r *flexibleSliceArgResolver) Resolve(res resolution, scope *Scope, a *flexibleSliceArg) (any, error) {
 	// First try to match by the slice type.
 	if boundTo, ok := scope.GetBoundArgInChain(a.Type()); ok {
-		return r.resolver.Resolve(scope, boundTo)
+		return r.resolver.resolve(res, scope, boundTo)
 	}
-	vals, err := scope.GetServicesByTypeInChain(a.Type())
+	vals, err := scope.getServicesByTypeInChain(res, a.Type())
 	if err != nil {
 		return nil, errorsx.Wrap(err, "failed to resolve flexible slice arg")
 	}
@@ -274,9 +362,9 @@ func (r *flexibleSliceArgResolver) Resolve(scope *Scope, a *flexibleSliceArg) (a
 	// Now let's try to match by the element type.
 	elemType := a.Type().Elem()
 	if boundTo, ok := scope.GetBoundArgInChain(elemType); ok {
-		return r.resolver.Resolve(scope, boundTo)
+		return r.resolver.resolve(res, scope, boundTo)
 	}
-	vals, err = scope.GetServicesByTypeInChain(elemType)
+	vals, err = scope.getServicesByTypeInChain(res, elemType)
 	if err != nil {
 		return nil, errorsx.Wrap(err, "failed to resolve flexible slice arg element")
 	}
@@ -323,10 +411,10 @@ func (r *compoundArgResolver) Validate(scope *Scope, a *compoundArg) error {
 	return joinedErr
 }
 
-func (r *compoundArgResolver) Resolve(scope *Scope, a *compoundArg) (any, error) {
+func (r *compoundArgResolver) Resolve(res resolution, scope *Scope, a *compoundArg) (any, error) {
 	vals := make([]any, len(a.args))
 	for i, arg := range a.args {
-		v, err := r.resolver.Resolve(scope, arg)
+		v, err := r.resolver.resolve(res, scope, arg)
 		if err != nil {
 			return nil, errorsx.Wrapf(err, "failed to resolve compound sub-arg %d", i)
 		}
@@ -341,6 +429,135 @@ func (r *compoundArgResolver) ResolveIDs(scope *Scope, a *compoundArg) []ID {
 	})
 }
 
+type mapArgResolver struct {
+	resolver *ArgResolver
+}
+
+type mapEntry struct {
+	key reflect.Value
+	arg Arg
+}
+
+func (r *mapArgResolver) Validate(scope *Scope, a *mapArg) error {
+	entries, err := r.entries(scope, a)
+	if err != nil {
+		return err
+	}
+
+	var joinedErr error
+	for i, entry := range entries {
+		err := r.resolver.Validate(scope, entry.arg)
+		if err != nil {
+			joinedErr = errors.Join(joinedErr, errorsx.Wrapf(err, "failed to resolve map sub-arg %d", i))
+		}
+	}
+	return joinedErr
+}
+
+func (r *mapArgResolver) Resolve(res resolution, scope *Scope, a *mapArg) (any, error) {
+	entries, err := r.entries(scope, a)
+	if err != nil {
+		return nil, err
+	}
+
+	m := reflect.MakeMapWithSize(a.typ, len(entries))
+	for i, entry := range entries {
+		v, err := r.resolver.resolve(res, scope, entry.arg)
+		if err != nil {
+			return nil, errorsx.Wrapf(err, "failed to resolve map sub-arg %d", i)
+		}
+		rv := reflect.ValueOf(v)
+		if !rv.Type().AssignableTo(a.typ.Elem()) {
+			return nil, fmt.Errorf("type %s is not assignable to %s", util.Signature(rv.Type()), util.Signature(a.typ.Elem()))
+		}
+		m.SetMapIndex(entry.key, rv)
+	}
+	return m.Interface(), nil
+}
+
+func (r *mapArgResolver) ResolveIDs(scope *Scope, a *mapArg) []ID {
+	entries, _ := r.entries(scope, a)
+	return lo.FlatMap(entries, func(entry mapEntry, _ int) []ID {
+		return r.resolver.ResolveIDs(scope, entry.arg)
+	})
+}
+
+// entries returns the keyed services that make up the map. It fails if two services have the same key.
+func (r *mapArgResolver) entries(scope *Scope, a *mapArg) ([]mapEntry, error) {
+	defs := scope.GetServiceDefinitionsInChain()
+	if a.label != "" {
+		defs = scope.GetServiceDefinitionsByLabelInChain(a.label)
+	}
+
+	var entries []mapEntry
+	byKey := make(map[any]*ServiceDefinition)
+	for _, def := range defs {
+		if def.Key() == "" || !def.Type().AssignableTo(a.typ.Elem()) {
+			continue
+		}
+
+		var key reflect.Value
+		if a.keyFn != nil {
+			key = reflect.ValueOf(a.keyFn(def.Key()))
+		} else {
+			key = reflect.ValueOf(def.Key()).Convert(a.typ.Key())
+		}
+		if !key.IsValid() || !key.Type().AssignableTo(a.typ.Key()) {
+			return nil, fmt.Errorf("key of service %s is not assignable to %s", def, util.Signature(a.typ.Key()))
+		}
+		if other, ok := byKey[key.Interface()]; ok {
+			return nil, fmt.Errorf("duplicate map key %v: %s and %s", key, other, def)
+		}
+		byKey[key.Interface()] = def
+
+		arg, _ := NewRefArg(def) // No error possible - def is not nil.
+		entries = append(entries, mapEntry{key: key, arg: arg})
+	}
+
+	if len(entries) == 0 {
+		if a.label != "" {
+			return nil, fmt.Errorf("no keyed services found for type %s with label %s", util.Signature(a.typ.Elem()), a.label)
+		}
+		return nil, fmt.Errorf("no keyed services found for type %s", util.Signature(a.typ.Elem()))
+	}
+
+	return entries, nil
+}
+
+type reversedArgResolver struct {
+	resolver *ArgResolver
+}
+
+func (r *reversedArgResolver) Validate(scope *Scope, a *reversedArg) error {
+	return r.resolver.Validate(scope, a.Arg)
+}
+
+func (r *reversedArgResolver) Resolve(res resolution, scope *Scope, a *reversedArg) (any, error) {
+	v, err := r.resolver.resolve(res, scope, a.Arg)
+	if err != nil {
+		return nil, err
+	}
+	return reverseSlice(v)
+}
+
+func (r *reversedArgResolver) ResolveIDs(scope *Scope, a *reversedArg) []ID {
+	ids := slices.Clone(r.resolver.ResolveIDs(scope, a.Arg))
+	slices.Reverse(ids)
+	return ids
+}
+
+func reverseSlice(v any) (any, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot reverse %s: not a slice", util.Signature(rv.Type()))
+	}
+	reversed := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+	for i := range rv.Len() {
+		reversed.Index(rv.Len() - 1 - i).Set(rv.Index(i))
+	}
+	return reversed.Interface(), nil
+}
+
 func convertSlice(vs []any, elemType reflect.Type) (any, error) {
 	sl := reflect.MakeSlice(reflect.SliceOf(elemType), 0, len(vs))
 	for _, v := range vs {
@@ -10,6 +10,7 @@ import (
 type InterfaceBinding struct {
 	ifaceTyp reflect.Type
 	boundTo  Arg
+	implicit bool // Created by the InterfaceBindingPass, rather than by the user.
 }
 
 func NewInterfaceBinding(iface reflect.Type, boundTo Arg) (*InterfaceBinding, error) {
@@ -2,7 +2,11 @@ package di
 
 import (
 	"cmp"
+	"errors"
+	"fmt"
+	"io"
 	"slices"
+	"strings"
 
 	"github.com/michalkurzeja/godi/v2/internal/errorsx"
 )
@@ -11,6 +15,8 @@ type CompilerPass struct {
 	name     string
 	stage    CompilerStage
 	priority int
+	after    []string
+	before   []string
 	op       CompilerOp
 }
 
@@ -18,11 +24,38 @@ func NewCompilerPass(name string, stage CompilerStage, op CompilerOp) *CompilerP
 	return &CompilerPass{name: name, stage: stage, op: op}
 }
 
+// WithPriority sets the priority of the pass. Within a stage, passes with a higher priority run earlier.
 func (p *CompilerPass) WithPriority(priority int) *CompilerPass {
 	p.priority = priority
 	return p
 }
 
+// After makes the pass run after the passes with the given names.
+// Passes that are not registered are ignored.
+func (p *CompilerPass) After(names ...string) *CompilerPass {
+	p.after = append(p.after, names...)
+	return p
+}
+
+// Before makes the pass run before the passes with the given names.
+// Passes that are not registered are ignored.
+func (p *CompilerPass) Before(names ...string) *CompilerPass {
+	p.before = append(p.before, names...)
+	return p
+}
+
+func (p *CompilerPass) Name() string {
+	return p.name
+}
+
+func (p *CompilerPass) Stage() CompilerStage {
+	return p.stage
+}
+
+func (p *CompilerPass) Priority() int {
+	return p.priority
+}
+
 func (p *CompilerPass) Run(builder *ContainerBuilder) error {
 	return p.op.Run(builder)
 }
@@ -55,6 +88,27 @@ const (
 	compilerPassStageCount
 )
 
+func (s CompilerStage) String() string {
+	switch s {
+	case PreAutomation:
+		return "pre-automation"
+	case Automation:
+		return "automation"
+	case PreValidation:
+		return "pre-validation"
+	case Validation:
+		return "validation"
+	case PreFinalization:
+		return "pre-finalization"
+	case Finalization:
+		return "finalization"
+	case PostFinalization:
+		return "post-finalization"
+	default:
+		return fmt.Sprintf("stage(%d)", uint8(s))
+	}
+}
+
 // Passes contains an ordered list of Compiler passes.
 // It is organised into stages and priorities. This makes it possible
 // to control when the pass is executed.
@@ -62,31 +116,145 @@ const (
 // are executed by their priority: the higher the priority, the earlier
 // the pass will run. If two passes have the same priority, they will
 // be executed in the order they were added.
+// On top of that, a pass can declare that it must run after or before
+// other passes (see CompilerPass.After and CompilerPass.Before).
+// These constraints take precedence over the priorities.
 type Passes []*CompilerPass
 
 func BasePasses(skipCycleValidation bool) Passes {
 	passes := Passes{
 		NewCompilerPass("interface binding", Automation, NewInterfaceBindingPass()),
-		NewCompilerPass("autowiring", Automation, NewAutowiringPass()),
+		NewCompilerPass("autowiring", Automation, NewAutowiringPass()).After("interface binding"),
+		NewCompilerPass("name validation", Validation, NewNameValidationPass()),
 		NewCompilerPass("argument validation", Validation, NewArgValidationPass()),
 		NewCompilerPass("eager initialization", Finalization, NewEagerInitPass()),
+		NewCompilerPass("binding report", PostFinalization, NewBindingReportPass()),
+		NewCompilerPass("label report", PostFinalization, NewLabelReportPass()),
 	}
 	if !skipCycleValidation {
-		passes = append(passes, NewCompilerPass("cycle validation", Validation, NewCycleValidationPass()))
+		passes = append(passes, NewCompilerPass("cycle validation", Validation, NewCycleValidationPass()).After("argument validation"))
 	}
 	return passes
 }
 
-func (passes Passes) sort() {
-	slices.SortFunc(passes, func(a, b *CompilerPass) int {
+// String returns the passes in their order, one per line.
+func (passes Passes) String() string {
+	var sb strings.Builder
+	for i, pass := range passes {
+		_, _ = fmt.Fprintf(&sb, "%d. %s (stage: %s, priority: %d)\n", i+1, pass, pass.stage, pass.priority)
+	}
+	return sb.String()
+}
+
+// sorted returns the passes in the order of execution.
+// It fails if the After/Before constraints of the passes are in conflict.
+func (passes Passes) sorted() (Passes, error) {
+	sorted := slices.Clone(passes)
+	slices.SortStableFunc(sorted, func(a, b *CompilerPass) int {
 		if a.stage != b.stage {
 			return cmp.Compare(a.stage, b.stage)
 		}
-		if a.priority != b.priority {
-			return cmp.Compare(a.priority, b.priority)
-		}
-		return 0
+		return cmp.Compare(b.priority, a.priority)
 	})
+
+	byName := make(map[string][]*CompilerPass, len(sorted))
+	for _, pass := range sorted {
+		byName[pass.name] = append(byName[pass.name], pass)
+	}
+
+	// Constraints between passes of different stages can't change the order, they can only be violated.
+	var joinedErrs error
+	for _, pass := range sorted {
+		for _, name := range pass.after {
+			for _, other := range byName[name] {
+				if other.stage > pass.stage {
+					joinedErrs = errors.Join(joinedErrs, fmt.Errorf("pass %s (stage: %s) cannot run after %s (stage: %s)", pass, pass.stage, other, other.stage))
+				}
+			}
+		}
+		for _, name := range pass.before {
+			for _, other := range byName[name] {
+				if other.stage < pass.stage {
+					joinedErrs = errors.Join(joinedErrs, fmt.Errorf("pass %s (stage: %s) cannot run before %s (stage: %s)", pass, pass.stage, other, other.stage))
+				}
+			}
+		}
+	}
+	if joinedErrs != nil {
+		return nil, errorsx.Wrap(joinedErrs, "conflicting compiler pass constraints")
+	}
+
+	result := make(Passes, 0, len(sorted))
+	for start := 0; start < len(sorted); {
+		end := start
+		for end < len(sorted) && sorted[end].stage == sorted[start].stage {
+			end++
+		}
+		stagePasses, err := sortStage(sorted[start:end], byName)
+		if err != nil {
+			return nil, errorsx.Wrap(err, "conflicting compiler pass constraints")
+		}
+		result = append(result, stagePasses...)
+		start = end
+	}
+
+	return result, nil
+}
+
+// sortStage topologically sorts the passes of a single stage by their After/Before constraints.
+// Passes that are not constrained keep their relative (priority) order.
+func sortStage(passes Passes, byName map[string][]*CompilerPass) (Passes, error) {
+	inStage := make(map[*CompilerPass]bool, len(passes))
+	for _, pass := range passes {
+		inStage[pass] = true
+	}
+
+	successors := make(map[*CompilerPass][]*CompilerPass, len(passes))
+	inDegree := make(map[*CompilerPass]int, len(passes))
+	addEdge := func(from, to *CompilerPass) {
+		if from == to || !inStage[from] || !inStage[to] {
+			return
+		}
+		successors[from] = append(successors[from], to)
+		inDegree[to]++
+	}
+	for _, pass := range passes {
+		for _, name := range pass.after {
+			for _, other := range byName[name] {
+				addEdge(other, pass)
+			}
+		}
+		for _, name := range pass.before {
+			for _, other := range byName[name] {
+				addEdge(pass, other)
+			}
+		}
+	}
+
+	remaining := slices.Clone(passes)
+	result := make(Passes, 0, len(passes))
+	for len(remaining) > 0 {
+		i := slices.IndexFunc(remaining, func(pass *CompilerPass) bool { return inDegree[pass] == 0 })
+		if i < 0 {
+			return nil, fmt.Errorf("cyclic order of passes in stage %s: %s", remaining[0].stage, joinPasses(remaining))
+		}
+		pass := remaining[i]
+		remaining = slices.Delete(remaining, i, i+1)
+		for _, next := range successors[pass] {
+			inDegree[next]--
+		}
+		result = append(result, pass)
+	}
+
+	return result, nil
+}
+
+func joinPasses(passes Passes) string {
+	names := make([]string, len(passes))
+	for i, pass := range passes {
+		names[i] = pass.String()
+	}
+	return strings.Join(names, ", ")
 }
 
 // Compiler is responsible for configuration of the container after all user changes are done.
@@ -97,16 +265,34 @@ type Compiler struct {
 }
 
 func NewCompiler(conf CompilerConfig) *Compiler {
-	return &Compiler{passes: BasePasses(conf.SkipCycleValidation)}
+	c := &Compiler{passes: BasePasses(conf.SkipCycleValidation)}
+	if !conf.SkipResolutionPlanning {
+		c.AddPass(NewCompilerPass("resolution planning", Finalization, NewResolutionPlanningPass()).Before("eager initialization"))
+	}
+	if conf.ReportUnused {
+		c.AddPass(NewCompilerPass("unused services report", PostFinalization, NewUnusedReportPass(conf.UnusedReport)))
+	}
+	return c
 }
 
 func (c *Compiler) AddPass(pass *CompilerPass) {
 	c.passes = append(c.passes, pass)
 }
 
+// Passes returns the registered passes in the order of execution.
+// It fails if the After/Before constraints of the passes are in conflict.
+func (c *Compiler) Passes() (Passes, error) {
+	return c.passes.sorted()
+}
+
 func (c *Compiler) Run(builder *ContainerBuilder) error {
-	c.passes.sort()
-	for _, pass := range c.passes {
+	passes, err := c.Passes()
+	if err != nil {
+		return err
+	}
+	defer func() { builder.currentPass = nil }()
+	for _, pass := range passes {
+		builder.currentPass = pass
 		err := pass.Run(builder)
 		if err != nil {
 			return errorsx.Wrapf(err, "compiler pass (%s) returned an error", pass)
@@ -121,6 +307,13 @@ type CompilerConfig struct {
 	// It is, however, a costly operation, so it can be disabled to increase the performance of the container building process.
 	// Be aware that disabling the cycle validation can lead to stack overflow errors if the user creates a cycle in the container.
 	SkipCycleValidation bool
+	// SkipResolutionPlanning disables the resolution planning compiler pass. Without the precompiled plans,
+	// the arguments are resolved by looking up the services (by type, label, etc.) on each instantiation.
+	SkipResolutionPlanning bool
+	// ReportUnused enables the report of unused services. Each unused service is reported as a warning.
+	ReportUnused bool
+	// UnusedReport is an optional writer that receives the report of unused services.
+	UnusedReport io.Writer
 }
 
 func NewCompilerConfig() CompilerConfig {
@@ -3,7 +3,9 @@ package di
 import (
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"slices"
 
 	"github.com/dominikbraun/graph"
 	"github.com/samber/lo"
@@ -91,6 +93,7 @@ func (p *InterfaceBindingPass) checkAndBind(scope *Scope, parentID ID, slot *Slo
 	if err != nil {
 		return err
 	}
+	binding.implicit = true
 
 	scope.AddBindings(binding)
 
@@ -104,7 +107,7 @@ func (p *InterfaceBindingPass) findImplementations(scope *Scope, parentID ID, if
 			impls = append(impls, def)
 		}
 	}
-	return impls
+	return sortByPriority(impls)
 }
 
 type autowiringPass struct{}
@@ -216,6 +219,42 @@ func (p *argValidationPass) validateArgs(scope *Scope, args *ArgList) error {
 	return joinedErr
 }
 
+// NewNameValidationPass returns a compiler pass that validates that service names are unique
+// within every scope chain, i.e. a service cannot share a name with a service from its own scope or any parent scope.
+func NewNameValidationPass() CompilerOpFunc {
+	return func(builder *ContainerBuilder) error {
+		var joinedErr error
+		for scope := range builder.Scopes() {
+			seen := make(map[string]*ServiceDefinition)
+			for def := range scope.ServiceDefinitionsSeq() {
+				if def.Name() == "" {
+					continue
+				}
+				if other, ok := seen[def.Name()]; ok {
+					joinedErr = errors.Join(joinedErr, duplicateNameError(other, scope, def, scope))
+					continue
+				}
+				seen[def.Name()] = def
+
+				if scope.Parent() == nil {
+					continue
+				}
+				for parent := range scope.Parent().Chain() {
+					if other, ok := parent.Services().GetByName(def.Name()); ok {
+						joinedErr = errors.Join(joinedErr, duplicateNameError(other, parent, def, scope))
+					}
+				}
+			}
+		}
+		return joinedErr
+	}
+}
+
+func duplicateNameError(def1 *ServiceDefinition, scope1 *Scope, def2 *ServiceDefinition, scope2 *Scope) error {
+	return fmt.Errorf("duplicate service name %q: %s (factory: %s, scope: %s) and %s (factory: %s, scope: %s)",
+		def1.Name(), def1, def1.FactoryName(), scope1, def2, def2.FactoryName(), scope2)
+}
+
 // NewCycleValidationPass returns a compiler pass that validates that there are no circular references.
 func NewCycleValidationPass() CompilerOpFunc {
 	return func(builder *ContainerBuilder) error {
@@ -248,6 +287,79 @@ func NewCycleValidationPass() CompilerOpFunc {
 	}
 }
 
+// NewPruningPass returns a compiler pass that removes all service and function definitions
+// that are not reachable from the roots. The roots are resolved when the pass runs, so they
+// can be selected based on the state of the container at that point.
+// It should run after autowiring, so that all the dependencies are known.
+func NewPruningPass(roots func(builder *ContainerBuilder) ([]ID, error)) CompilerOpFunc {
+	return func(builder *ContainerBuilder) error {
+		ids, err := roots(builder)
+		if err != nil {
+			return errorsx.Wrap(err, "failed to resolve roots")
+		}
+		reachable, err := ReachableIDs(builder, ids...)
+		if err != nil {
+			return err
+		}
+
+		keep := lo.Keyify(reachable)
+		for scope := range builder.Scopes() {
+			var svcIDs, funIDs []ID
+			for def := range scope.ServiceDefinitionsSeq() {
+				if _, ok := keep[def.ID()]; !ok {
+					svcIDs = append(svcIDs, def.ID())
+				}
+			}
+			for def := range scope.FunctionDefinitionsSeq() {
+				if _, ok := keep[def.ID()]; !ok {
+					funIDs = append(funIDs, def.ID())
+				}
+			}
+			scope.RemoveServiceDefinitions(svcIDs...)
+			scope.RemoveFunctionDefinitions(funIDs...)
+		}
+
+		return nil
+	}
+}
+
+// ReachableIDs returns the IDs of all the definitions that are reachable from the given roots,
+// including the roots themselves. The roots may be IDs of both services and functions.
+func ReachableIDs(builder *ContainerBuilder, roots ...ID) ([]ID, error) {
+	for _, id := range roots {
+		_, isSvc := builder.ServiceDefinition(id)
+		_, isFun := builder.FunctionDefinition(id)
+		if !isSvc && !isFun {
+			return nil, fmt.Errorf("root definition %s not found", id)
+		}
+	}
+
+	var (
+		reachable []ID
+		visited   = make(map[ID]struct{})
+		queue     = slices.Clone(roots)
+	)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if _, ok := visited[id]; ok {
+			continue
+		}
+		visited[id] = struct{}{}
+
+		if def, ok := builder.ServiceDefinition(id); ok {
+			queue = append(queue, ResolveServiceDependencyIDs(def)...)
+		} else if def, ok := builder.FunctionDefinition(id); ok {
+			queue = append(queue, ResolveFunctionDependencyIDs(def)...)
+		} else {
+			continue // Dangling references are reported by the argument validation.
+		}
+		reachable = append(reachable, id)
+	}
+	return reachable, nil
+}
+
 // stage: Finalization
 
 // NewEagerInitPass returns a compiler pass that initializes all eager services and functions.
@@ -274,3 +386,194 @@ func NewEagerInitPass() CompilerOpFunc {
 		return nil
 	}
 }
+
+// stage: PostFinalization
+
+// NewUnusedReportPass returns a compiler pass that emits a warning for each unused service.
+// The warnings are also written to w, unless it's nil.
+// A service is unused if it's lazy, it's not labelled as an EntryPoint, and it's not referenced
+// by any other service, function or interface binding.
+func NewUnusedReportPass(w io.Writer) CompilerOpFunc {
+	return func(builder *ContainerBuilder) error {
+		used := make(map[ID]struct{})
+		for _, def := range builder.ServiceDefinitionsSeq() {
+			for _, id := range ResolveServiceDependencyIDs(def) {
+				used[id] = struct{}{}
+			}
+		}
+		for _, def := range builder.FunctionDefinitionsSeq() {
+			for _, id := range ResolveFunctionDependencyIDs(def) {
+				used[id] = struct{}{}
+			}
+		}
+		for scope := range builder.Scopes() {
+			for binding := range scope.BindingsSeq() {
+				for _, id := range ResolveArgIDs(scope, binding.BoundTo()) {
+					used[id] = struct{}{}
+				}
+			}
+		}
+
+		for scope, def := range builder.ServiceDefinitionsSeq() {
+			if _, ok := used[def.ID()]; ok || !def.IsLazy() || slices.Contains(def.Labels(), EntryPoint) {
+				continue
+			}
+			msg := fmt.Sprintf("unused service %s (factory: %s, scope: %s)", def, def.FactoryName(), scope)
+			builder.Warn("%s", msg)
+			if w == nil {
+				continue
+			}
+			if _, err := io.WriteString(w, msg+"\n"); err != nil {
+				return errorsx.Wrap(err, "failed to write the unused services report")
+			}
+		}
+
+		return nil
+	}
+}
+
+// NewBindingReportPass returns a compiler pass that emits a warning for each interface binding that no argument
+// is resolved with, and for each binding that picks one of multiple implementations of the interface,
+// which would otherwise be ambiguous. Only the bindings given by the user are reported.
+func NewBindingReportPass() CompilerOpFunc {
+	return func(builder *ContainerBuilder) error {
+		used := make(map[*InterfaceBinding]bool)
+		markUsed := func(scope *Scope, arg Arg) {
+			walkArg(arg, func(arg Arg) {
+				var binding *InterfaceBinding
+				switch a := arg.(type) {
+				case *typeArg:
+					binding, _ = scope.GetBindingInChain(a.typ)
+				case *flexibleSliceArg:
+					var ok bool
+					if binding, ok = scope.GetBindingInChain(a.Type()); !ok {
+						binding, _ = scope.GetBindingInChain(a.elemType)
+					}
+				}
+				if binding != nil {
+					used[binding] = true
+				}
+			})
+		}
+		forEachArg(builder, markUsed)
+
+		for scope := range builder.Scopes() {
+			for binding := range scope.BindingsSeq() {
+				if binding.implicit {
+					continue
+				}
+				iface, boundTo := util.Signature(binding.Interface()), binding.BoundTo()
+				if !used[binding] {
+					builder.Warn("unused binding of interface %s to %s (scope: %s)", iface, boundTo, scope)
+					continue
+				}
+				if _, ok := boundTo.(*compoundArg); ok {
+					continue // Bound to many implementations, there is nothing to pick.
+				}
+				impls := new(InterfaceBindingPass).findImplementations(scope, "", binding.Interface())
+				if len(impls) > 1 {
+					builder.Warn("interface %s has multiple implementations %s, resolved by the binding to %s (scope: %s)", iface, impls, boundTo, scope)
+				}
+			}
+		}
+
+		return nil
+	}
+}
+
+// NewLabelReportPass returns a compiler pass that emits a warning for each label that is used only once:
+// it's given to a single service or function, and neither an argument nor a compiler pass (see ContainerBuilder.UseLabels)
+// references it. Such a label is often a typo. Labels that are only used to retrieve services from the container
+// (e.g. with SvcByLabel) are reported as well.
+func NewLabelReportPass() CompilerOpFunc {
+	return func(builder *ContainerBuilder) error {
+		var labels []Label
+		uses := make(map[Label]int)
+		holders := make(map[Label]string)
+		use := func(label Label, holder string) {
+			if _, ok := uses[label]; !ok {
+				labels = append(labels, label)
+			}
+			uses[label]++
+			if holder != "" {
+				holders[label] = holder
+			}
+		}
+
+		for scope, def := range builder.ServiceDefinitionsSeq() {
+			for _, label := range def.Labels() {
+				use(label, fmt.Sprintf("service %s (factory: %s, scope: %s)", def, def.FactoryName(), scope))
+			}
+		}
+		for scope, def := range builder.FunctionDefinitionsSeq() {
+			for _, label := range def.Labels() {
+				use(label, fmt.Sprintf("function %s (scope: %s)", def, scope))
+			}
+		}
+		forEachArg(builder, func(_ *Scope, arg Arg) {
+			walkArg(arg, func(arg Arg) {
+				switch a := arg.(type) {
+				case *labelArg:
+					use(a.label, "")
+				case *mapArg:
+					if a.label != "" {
+						use(a.label, "")
+					}
+				}
+			})
+		})
+
+		for label := range builder.usedLabels {
+			use(label, "")
+		}
+
+		for _, label := range labels {
+			if holder, ok := holders[label]; ok && uses[label] == 1 && label != EntryPoint {
+				builder.Warn("label %s is used only once, by %s", label, holder)
+			}
+		}
+
+		return nil
+	}
+}
+
+// forEachArg calls fn with each argument of the factories, method calls, functions and interface bindings,
+// together with the scope it's resolved in.
+func forEachArg(builder *ContainerBuilder, fn func(scope *Scope, arg Arg)) {
+	forEachSlot := func(scope *Scope, args *ArgList) {
+		for _, slot := range args.Slots() {
+			if arg := slot.Arg(); arg != nil {
+				fn(scope, arg)
+			}
+		}
+	}
+	for _, def := range builder.ServiceDefinitionsSeq() {
+		forEachSlot(def.EffectiveScope(), def.Factory().Args())
+		for _, method := range def.MethodCalls() {
+			forEachSlot(def.EffectiveScope(), method.Args())
+		}
+	}
+	for _, def := range builder.FunctionDefinitionsSeq() {
+		forEachSlot(def.EffectiveScope(), def.Func().Args())
+	}
+	for scope := range builder.Scopes() {
+		for binding := range scope.BindingsSeq() {
+			fn(scope, binding.BoundTo())
+		}
+	}
+}
+
+// walkArg calls fn with the argument and with all arguments nested in it.
+func walkArg(arg Arg, fn func(Arg)) {
+	fn(arg)
+	switch a := arg.(type) {
+	case *compoundArg:
+		for _, sub := range a.args {
+			walkArg(sub, fn)
+		}
+	case *reversedArg:
+		walkArg(a.Arg, fn)
+	case *SlottedArg:
+		walkArg(a.Arg, fn)
+	}
+}
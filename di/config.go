@@ -2,6 +2,18 @@ package di
 
 type Config struct {
 	CompilerConfig
+	// WarningHandler is called for each warning emitted during the build.
+	// Regardless of the handler, all warnings are collected by the ContainerBuilder.
+	WarningHandler WarningHandler
+	// Interceptors observe the calls made by the container, see Interceptor.
+	Interceptors []Interceptor
+	// DeterministicIDs makes the IDs of definitions derived from the scope path, the factory (or function) name
+	// and the index of registration within the scope, instead of random. This makes the IDs (and everything
+	// that mentions them, like Print output and error messages) reproducible across runs of the same binary.
+	DeterministicIDs bool
+	// Stats makes the container collect the runtime counters of the definitions, see Container.Stats.
+	// It's disabled by default, as it adds a cost to every resolution, including the retrieval of cached services.
+	Stats bool
 }
 
 func NewConfig() Config {
@@ -1,8 +1,10 @@
 package di
 
 import (
+	"context"
 	"io"
 	"reflect"
+	"sync/atomic"
 
 	"github.com/elliotchance/orderedmap/v2"
 )
@@ -12,14 +14,31 @@ const RootScope = "root"
 type Container struct {
 	root   *Scope
 	scopes *orderedmap.OrderedMap[string, *Scope]
+
+	interceptors    []Interceptor
+	refreshHandlers []RefreshHandler
+	stats           *stats            // Nil unless the stats are collected.
+	callIDs         atomic.Uint64     // Source of the IDs of the intercepted calls.
+	ids             *deterministicIDs // Nil unless the IDs are deterministic.
+	conf            Config            // The configuration the container is built with, reused by derived containers.
 }
 
 func NewContainer() *Container {
-	c := &Container{scopes: orderedmap.NewOrderedMap[string, *Scope]()}
+	c := &Container{
+		scopes: orderedmap.NewOrderedMap[string, *Scope](),
+	}
 	c.root = NewScope(RootScope, c, nil)
 	return c
 }
 
+// UseDeterministicIDs makes the container assign deterministic IDs to all definitions added from now on.
+// See Config.DeterministicIDs.
+func (c *Container) UseDeterministicIDs() {
+	if c.ids == nil {
+		c.ids = newDeterministicIDs()
+	}
+}
+
 func (c *Container) HasService(id ID) bool {
 	return c.root.HasService(id)
 }
@@ -28,6 +47,10 @@ func (c *Container) GetService(id ID) (any, error) {
 	return c.root.GetService(id)
 }
 
+func (c *Container) GetServiceByName(name string) (any, error) {
+	return c.root.GetServiceByName(name)
+}
+
 func (c *Container) GetServices(ids ...ID) ([]any, error) {
 	return c.root.GetServices(ids...)
 }
@@ -40,6 +63,10 @@ func (c *Container) GetServicesByType(typ reflect.Type) ([]any, error) {
 	return c.root.GetServicesByType(typ)
 }
 
+func (c *Container) GetServicesByTypeContext(ctx context.Context, typ reflect.Type) ([]any, error) {
+	return c.root.GetServicesByTypeContext(ctx, typ)
+}
+
 func (c *Container) GetServicesIDsByLabel(label Label) []ID {
 	return c.root.GetServicesIDsByLabel(label)
 }
@@ -48,6 +75,10 @@ func (c *Container) GetServicesByLabel(label Label) ([]any, error) {
 	return c.root.GetServicesByLabel(label)
 }
 
+func (c *Container) GetTaggedServices(tag Label) ([]TaggedService, error) {
+	return c.root.GetTaggedServices(tag)
+}
+
 func (c *Container) HasFunction(id ID) bool {
 	return c.root.HasFunction(id)
 }
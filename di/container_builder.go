@@ -2,6 +2,7 @@ package di
 
 import (
 	"errors"
+	"fmt"
 	"iter"
 
 	"github.com/michalkurzeja/godi/v2/internal/errorsx"
@@ -12,17 +13,34 @@ import (
 // inspect and configure the container.
 // Once Build() is called, this builder is locked and no longer usable. Subsequent calls
 // to Build() will return an error and any other method may panic.
+// The definitions, scopes and bindings of the built container are frozen: modifying them panics.
 type ContainerBuilder struct {
 	container *Container
 	compiler  *Compiler
 
+	warnings       []Warning
+	warningHandler WarningHandler
+	currentPass    *CompilerPass
+	usedLabels     map[Label]struct{} // Labels that the compiler passes look services up by, see UseLabels.
+
 	built bool
 }
 
 func NewContainerBuilder(conf Config) *ContainerBuilder {
+	container := NewContainer()
+	container.conf = conf
+	container.AddInterceptors(conf.Interceptors...)
+	if conf.DeterministicIDs {
+		container.UseDeterministicIDs()
+	}
+	if conf.Stats {
+		container.collectStats()
+	}
+
 	return &ContainerBuilder{
-		container: NewContainer(),
-		compiler:  NewCompiler(conf.CompilerConfig),
+		container:      container,
+		compiler:       NewCompiler(conf.CompilerConfig),
+		warningHandler: conf.WarningHandler,
 	}
 }
 
@@ -62,6 +80,55 @@ func (b *ContainerBuilder) FunctionDefinitionsSeq() iter.Seq2[*Scope, *FunctionD
 	}
 }
 
+// ServiceDefinition returns the service definition with the given ID, regardless of the scope it belongs to.
+func (b *ContainerBuilder) ServiceDefinition(id ID) (*ServiceDefinition, bool) {
+	for scope := range b.Scopes() {
+		if def, ok := scope.GetServiceDefinition(id); ok {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// FunctionDefinition returns the function definition with the given ID, regardless of the scope it belongs to.
+func (b *ContainerBuilder) FunctionDefinition(id ID) (*FunctionDefinition, bool) {
+	for scope := range b.Scopes() {
+		if def, ok := scope.GetFunctionDefinition(id); ok {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// Warn emits a warning. Compiler passes use it to report issues that should not fail the build.
+func (b *ContainerBuilder) Warn(format string, args ...any) {
+	w := Warning{Message: fmt.Sprintf(format, args...)}
+	if b.currentPass != nil {
+		w.Pass = b.currentPass.String()
+	}
+
+	b.warnings = append(b.warnings, w)
+	if b.warningHandler != nil {
+		b.warningHandler(w)
+	}
+}
+
+// UseLabels marks the labels as used by a compiler pass that looks the services up by them (e.g. to collect them),
+// so that the label report doesn't warn about labels given to a single definition.
+func (b *ContainerBuilder) UseLabels(labels ...Label) {
+	if b.usedLabels == nil {
+		b.usedLabels = make(map[Label]struct{})
+	}
+	for _, label := range labels {
+		b.usedLabels[label] = struct{}{}
+	}
+}
+
+// Warnings returns all warnings emitted so far.
+func (b *ContainerBuilder) Warnings() []Warning {
+	return b.warnings
+}
+
 func (b *ContainerBuilder) Compiler() *Compiler {
 	return b.compiler
 }
@@ -78,6 +145,7 @@ func (b *ContainerBuilder) Build() (*Container, error) {
 	}
 
 	container := b.container
+	container.freeze()
 	b.container = nil
 
 	return container, nil
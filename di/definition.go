@@ -43,13 +43,23 @@ func (id ID) String() string {
 
 type Label string
 
+// EntryPoint labels services that are used directly (e.g. retrieved from the container),
+// rather than as dependencies of other services.
+const EntryPoint Label = "godi.entry-point"
+
 func (l Label) String() string {
 	return string(l)
 }
 
 type ServiceDefinition struct {
 	id     ID
+	name   string
+	key    string
 	labels []Label
+	tags   []Tag
+
+	// priority determines the order of the service in slices, the higher the earlier.
+	priority int
 
 	factory     *Factory
 	methodCalls map[string]*Method
@@ -58,9 +68,12 @@ type ServiceDefinition struct {
 	childScope *Scope
 
 	// Properties
-	lazy      bool
-	shared    bool
-	autowired bool
+	lazy        bool
+	shared      bool
+	autowired   bool
+	errorPolicy ErrorPolicy
+
+	frozen bool
 }
 
 func NewServiceDefinition(factory *Factory) *ServiceDefinition {
@@ -89,6 +102,7 @@ func (d *ServiceDefinition) Scope() *Scope {
 }
 
 func (d *ServiceDefinition) SetScope(scope *Scope) *ServiceDefinition {
+	d.mustNotBeFrozen()
 	d.scope = scope
 	return d
 }
@@ -98,6 +112,7 @@ func (d *ServiceDefinition) ChildScope() *Scope {
 }
 
 func (d *ServiceDefinition) SetChildScope(scope *Scope) *ServiceDefinition {
+	d.mustNotBeFrozen()
 	d.childScope = scope
 	return d
 }
@@ -117,6 +132,7 @@ func (d *ServiceDefinition) Factory() *Factory {
 }
 
 func (d *ServiceDefinition) SetFactory(factory *Factory) *ServiceDefinition {
+	d.mustNotBeFrozen()
 	d.factory = factory
 	return d
 }
@@ -128,11 +144,13 @@ func (d *ServiceDefinition) MethodCalls() []*Method {
 }
 
 func (d *ServiceDefinition) SetMethodCalls(methodCalls ...*Method) *ServiceDefinition {
+	d.mustNotBeFrozen()
 	d.methodCalls = make(map[string]*Method)
 	return d.AddMethodCalls(methodCalls...)
 }
 
 func (d *ServiceDefinition) AddMethodCalls(methodCalls ...*Method) *ServiceDefinition {
+	d.mustNotBeFrozen()
 	for _, call := range methodCalls {
 		d.methodCalls[call.Name()] = call
 	}
@@ -140,34 +158,99 @@ func (d *ServiceDefinition) AddMethodCalls(methodCalls ...*Method) *ServiceDefin
 }
 
 func (d *ServiceDefinition) RemoveMethodCalls(names ...string) *ServiceDefinition {
+	d.mustNotBeFrozen()
 	d.methodCalls = lo.OmitByKeys(d.methodCalls, names)
 	return d
 }
 
+// Name returns the unique name of the service, or an empty string if the service is not named.
+func (d *ServiceDefinition) Name() string {
+	return d.name
+}
+
+func (d *ServiceDefinition) SetName(name string) *ServiceDefinition {
+	d.mustNotBeFrozen()
+	d.name = name
+	return d
+}
+
+// Key returns the key of the service in injected maps (see NewMapArg).
+// If no key is set, the name of the service is used.
+func (d *ServiceDefinition) Key() string {
+	if d.key != "" {
+		return d.key
+	}
+	return d.name
+}
+
+func (d *ServiceDefinition) SetKey(key string) *ServiceDefinition {
+	d.mustNotBeFrozen()
+	d.key = key
+	return d
+}
+
+// Labels returns the labels of the definition, including the names of its tags.
 func (d *ServiceDefinition) Labels() []Label {
-	return d.labels
+	return tagLabels(d.labels, d.tags)
 }
 
 func (d *ServiceDefinition) SetLabels(labels ...Label) *ServiceDefinition {
+	d.mustNotBeFrozen()
 	d.labels = labels
 	return d
 }
 
 func (d *ServiceDefinition) AddLabels(labels ...Label) *ServiceDefinition {
+	d.mustNotBeFrozen()
 	d.labels = append(d.labels, labels...)
 	return d
 }
 
 func (d *ServiceDefinition) RemoveLabels(labels ...Label) *ServiceDefinition {
+	d.mustNotBeFrozen()
 	d.labels = lo.Without(d.labels, labels...)
 	return d
 }
 
+func (d *ServiceDefinition) Tags() []Tag {
+	return d.tags
+}
+
+// TagsByName returns all tags of the definition with the given name.
+func (d *ServiceDefinition) TagsByName(name Label) []Tag {
+	return tagsByName(d.tags, name)
+}
+
+func (d *ServiceDefinition) SetTags(tags ...Tag) *ServiceDefinition {
+	d.mustNotBeFrozen()
+	d.tags = tags
+	return d
+}
+
+func (d *ServiceDefinition) AddTags(tags ...Tag) *ServiceDefinition {
+	d.mustNotBeFrozen()
+	d.tags = append(d.tags, tags...)
+	return d
+}
+
+// Priority returns the priority of the service. Collections of services (e.g. injected slices)
+// are ordered by priority, the highest first, and then by the order of registration.
+func (d *ServiceDefinition) Priority() int {
+	return d.priority
+}
+
+func (d *ServiceDefinition) SetPriority(priority int) *ServiceDefinition {
+	d.mustNotBeFrozen()
+	d.priority = priority
+	return d
+}
+
 func (d *ServiceDefinition) IsLazy() bool {
 	return d.lazy
 }
 
 func (d *ServiceDefinition) SetLazy(lazy bool) *ServiceDefinition {
+	d.mustNotBeFrozen()
 	d.lazy = lazy
 	return d
 }
@@ -177,6 +260,7 @@ func (d *ServiceDefinition) IsShared() bool {
 }
 
 func (d *ServiceDefinition) SetShared(shared bool) *ServiceDefinition {
+	d.mustNotBeFrozen()
 	d.shared = shared
 	return d
 }
@@ -186,10 +270,22 @@ func (d *ServiceDefinition) IsAutowired() bool {
 }
 
 func (d *ServiceDefinition) SetAutowired(autowired bool) *ServiceDefinition {
+	d.mustNotBeFrozen()
 	d.autowired = autowired
 	return d
 }
 
+// ErrorPolicy returns the policy of handling the failed instantiations of the service.
+func (d *ServiceDefinition) ErrorPolicy() ErrorPolicy {
+	return d.errorPolicy
+}
+
+func (d *ServiceDefinition) SetErrorPolicy(policy ErrorPolicy) *ServiceDefinition {
+	d.mustNotBeFrozen()
+	d.errorPolicy = policy
+	return d
+}
+
 func (d *ServiceDefinition) FactoryName() string {
 	return d.factory.Name()
 }
@@ -218,6 +314,7 @@ type FunctionDefinition struct {
 	id       ID
 	function *Func
 	labels   []Label
+	tags     []Tag
 
 	scope      *Scope
 	childScope *Scope
@@ -225,6 +322,8 @@ type FunctionDefinition struct {
 	// Properties
 	lazy      bool
 	autowired bool
+
+	frozen bool
 }
 
 func NewFunctionDefinition(function *Func) *FunctionDefinition {
@@ -250,6 +349,7 @@ func (d *FunctionDefinition) Scope() *Scope {
 }
 
 func (d *FunctionDefinition) SetScope(scope *Scope) *FunctionDefinition {
+	d.mustNotBeFrozen()
 	d.scope = scope
 	return d
 }
@@ -259,6 +359,7 @@ func (d *FunctionDefinition) ChildScope() *Scope {
 }
 
 func (d *FunctionDefinition) SetChildScope(scope *Scope) *FunctionDefinition {
+	d.mustNotBeFrozen()
 	d.childScope = scope
 	return d
 }
@@ -278,34 +379,61 @@ func (d *FunctionDefinition) Func() *Func {
 }
 
 func (d *FunctionDefinition) SetFunc(fn *Func) *FunctionDefinition {
+	d.mustNotBeFrozen()
 	d.function = fn
 	return d
 }
 
+// Labels returns the labels of the definition, including the names of its tags.
 func (d *FunctionDefinition) Labels() []Label {
-	return d.labels
+	return tagLabels(d.labels, d.tags)
 }
 
 func (d *FunctionDefinition) SetLabels(labels ...Label) *FunctionDefinition {
+	d.mustNotBeFrozen()
 	d.labels = labels
 	return d
 }
 
 func (d *FunctionDefinition) AddLabels(labels ...Label) *FunctionDefinition {
+	d.mustNotBeFrozen()
 	d.labels = append(d.labels, labels...)
 	return d
 }
 
 func (d *FunctionDefinition) RemoveLabels(labels ...Label) *FunctionDefinition {
+	d.mustNotBeFrozen()
 	d.labels = lo.Without(d.labels, labels...)
 	return d
 }
 
+func (d *FunctionDefinition) Tags() []Tag {
+	return d.tags
+}
+
+// TagsByName returns all tags of the definition with the given name.
+func (d *FunctionDefinition) TagsByName(name Label) []Tag {
+	return tagsByName(d.tags, name)
+}
+
+func (d *FunctionDefinition) SetTags(tags ...Tag) *FunctionDefinition {
+	d.mustNotBeFrozen()
+	d.tags = tags
+	return d
+}
+
+func (d *FunctionDefinition) AddTags(tags ...Tag) *FunctionDefinition {
+	d.mustNotBeFrozen()
+	d.tags = append(d.tags, tags...)
+	return d
+}
+
 func (d *FunctionDefinition) IsLazy() bool {
 	return d.lazy
 }
 
 func (d *FunctionDefinition) SetLazy(lazy bool) *FunctionDefinition {
+	d.mustNotBeFrozen()
 	d.lazy = lazy
 	return d
 }
@@ -315,6 +443,7 @@ func (d *FunctionDefinition) IsAutowired() bool {
 }
 
 func (d *FunctionDefinition) SetAutowired(autowired bool) *FunctionDefinition {
+	d.mustNotBeFrozen()
 	d.autowired = autowired
 	return d
 }
@@ -0,0 +1,358 @@
+package di
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/michalkurzeja/godi/v2/internal/errorsx"
+	"github.com/michalkurzeja/godi/v2/internal/iterx"
+)
+
+type deriveConfig struct {
+	reuseInstances bool
+}
+
+// DeriveOption configures the derivation of a container, see Container.Derive.
+type DeriveOption func(*deriveConfig)
+
+// ReuseInstances makes the derived container reuse the instances of shared services, that are already
+// instantiated in the parent container, as long as the services and all their dependencies are defined
+// the same way in both containers.
+func ReuseInstances() DeriveOption {
+	return func(c *deriveConfig) {
+		c.reuseInstances = true
+	}
+}
+
+// DerivedBuilder returns a new builder with a deep copy of the definitions, scopes and bindings of the container.
+// The copy keeps the IDs of the definitions. Modify it and build it to get a new, independent container.
+// The compiler of the builder has only the base passes, as the passes of the parent have already
+// been applied to the copied definitions.
+func (c *Container) DerivedBuilder(opts ...DeriveOption) *ContainerBuilder {
+	var conf deriveConfig
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	builder := NewContainerBuilder(c.conf)
+	builder.container = c.clone()
+	if conf.reuseInstances {
+		builder.compiler.AddPass(NewCompilerPass("instance reuse", PreFinalization, newInstanceReusePass(c)))
+	}
+	return builder
+}
+
+// Derive builds a new container from a deep copy of this container, modified by the given function.
+// See DerivedBuilder.
+func (c *Container) Derive(modify func(builder *ContainerBuilder) error, opts ...DeriveOption) (*Container, error) {
+	builder := c.DerivedBuilder(opts...)
+	if modify != nil {
+		if err := modify(builder); err != nil {
+			return nil, errorsx.Wrap(err, "failed to modify the derived container")
+		}
+	}
+	return builder.Build()
+}
+
+// Clone returns a deep copy of the builder, with a copy of its definitions, scopes, bindings and compiler passes.
+// Both builders can be modified and built independently.
+func (b *ContainerBuilder) Clone() (*ContainerBuilder, error) {
+	if b.built {
+		return nil, errors.New("cannot clone a builder that is already built")
+	}
+
+	compiler := &Compiler{passes: slices.Clone(b.compiler.passes)}
+	return &ContainerBuilder{
+		container:      b.container.clone(),
+		compiler:       compiler,
+		warnings:       slices.Clone(b.warnings),
+		warningHandler: b.warningHandler,
+		usedLabels:     maps.Clone(b.usedLabels),
+	}, nil
+}
+
+// clone returns a deep copy of the container, without the instances of services and the stats.
+func (c *Container) clone() *Container {
+	clone := NewContainer()
+	clone.conf = c.conf
+	clone.interceptors = slices.Clone(c.interceptors)
+	if c.stats != nil {
+		clone.collectStats()
+	}
+
+	scopes := map[*Scope]*Scope{c.root: clone.root}
+	for scope := range iterx.Values(c.scopes.Iterator()) {
+		if scope == c.root {
+			continue
+		}
+		// Parents are always created before their children, so they are already copied.
+		scopes[scope] = NewScope(scope.name, clone, scopes[scope.parent])
+	}
+
+	if c.ids != nil {
+		clone.ids = c.ids.clone(scopes)
+	}
+
+	// Definitions are copied first, so that the references between them can be remapped.
+	svcs := make(map[*ServiceDefinition]*ServiceDefinition)
+	funs := make(map[*FunctionDefinition]*FunctionDefinition)
+	for scope := range iterx.Values(c.scopes.Iterator()) {
+		for def := range scope.svcs.Seq() {
+			svcs[def] = def.clone(scopes)
+		}
+		for def := range scope.funs.Seq() {
+			funs[def] = def.clone(scopes)
+		}
+	}
+
+	remap := func(arg Arg) Arg { return cloneArg(arg, svcs) }
+	for old, def := range svcs {
+		if old.factory != nil {
+			def.factory = old.factory.clone(remap)
+		}
+		for name, method := range old.methodCalls {
+			def.methodCalls[name] = method.clone(remap)
+		}
+	}
+	for old, def := range funs {
+		if old.function != nil {
+			def.function = old.function.clone(remap)
+		}
+	}
+
+	for scope := range iterx.Values(c.scopes.Iterator()) {
+		cloneScope := scopes[scope]
+		for def := range scope.svcs.Seq() {
+			cloneScope.svcs.Add(svcs[def])
+		}
+		for def := range scope.funs.Seq() {
+			cloneScope.funs.Add(funs[def])
+		}
+		for binding := range scope.BindingsSeq() {
+			cloneScope.bindings.Set(binding.ifaceTyp, &InterfaceBinding{ifaceTyp: binding.ifaceTyp, boundTo: remap(binding.boundTo), implicit: binding.implicit})
+		}
+	}
+
+	return clone
+}
+
+func (d *ServiceDefinition) clone(scopes map[*Scope]*Scope) *ServiceDefinition {
+	clone := *d
+	clone.labels = slices.Clone(d.labels)
+	clone.tags = cloneTags(d.tags)
+	clone.frozen = false
+	clone.factory = nil
+	clone.methodCalls = make(map[string]*Method, len(d.methodCalls))
+	clone.scope = scopes[d.scope]
+	clone.childScope = scopes[d.childScope]
+	return &clone
+}
+
+func (d *FunctionDefinition) clone(scopes map[*Scope]*Scope) *FunctionDefinition {
+	clone := *d
+	clone.labels = slices.Clone(d.labels)
+	clone.tags = cloneTags(d.tags)
+	clone.frozen = false
+	clone.function = nil
+	clone.scope = scopes[d.scope]
+	clone.childScope = scopes[d.childScope]
+	return &clone
+}
+
+func cloneTags(tags []Tag) []Tag {
+	if tags == nil {
+		return nil
+	}
+	clone := make([]Tag, len(tags))
+	for i, tag := range tags {
+		clone[i] = Tag{Name: tag.Name, Attrs: maps.Clone(tag.Attrs)}
+	}
+	return clone
+}
+
+func (f *Factory) clone(remap func(Arg) Arg) *Factory {
+	clone := *f
+	clone.fn = f.fn.clone(remap)
+	return &clone
+}
+
+func (m *Method) clone(remap func(Arg) Arg) *Method {
+	clone := *m
+	clone.fn = m.fn.clone(remap)
+	return &clone
+}
+
+func (f *Func) clone(remap func(Arg) Arg) *Func {
+	clone := *f
+	clone.plan = nil // The plan references the original definitions.
+	clone.origin = f.source()
+	clone.args = &ArgList{variadic: f.args.variadic, slots: make(Slots, len(f.args.slots))}
+	for i, slot := range f.args.slots {
+		clone.args.slots[i] = &Slot{
+			arg:      remap(slot.arg),
+			args:     mapArgs(slot.args, remap),
+			typ:      slot.typ,
+			i:        slot.i,
+			variadic: slot.variadic,
+		}
+	}
+	return &clone
+}
+
+// source returns the function that f is a (possibly transitive) copy of, or f itself.
+// Copies share the function value, while distinct functions may share its code (e.g. closures of the same literal),
+// so a common source is the only reliable way to tell that two functions are the same.
+func (f *Func) source() *Func {
+	if f.origin != nil {
+		return f.origin
+	}
+	return f
+}
+
+// cloneArg returns a copy of the argument that references the copies of the service definitions.
+// Arguments that don't reference definitions directly are immutable, so they are shared.
+func cloneArg(arg Arg, svcs map[*ServiceDefinition]*ServiceDefinition) Arg {
+	remap := func(arg Arg) Arg { return cloneArg(arg, svcs) }
+
+	switch a := arg.(type) {
+	case *refArg:
+		if def, ok := svcs[a.def]; ok {
+			return &refArg{def: def, typ: a.typ}
+		}
+		return a
+	case *compoundArg:
+		return &compoundArg{args: mapArgs(a.args, remap), typ: a.typ}
+	case *reversedArg:
+		return &reversedArg{Arg: remap(a.Arg)}
+	case *SlottedArg:
+		return NewSlottedArg(remap(a.Arg), a.slot)
+	default:
+		return arg
+	}
+}
+
+func mapArgs(args []Arg, remap func(Arg) Arg) []Arg {
+	if args == nil {
+		return nil
+	}
+	mapped := make([]Arg, len(args))
+	for i, arg := range args {
+		mapped[i] = remap(arg)
+	}
+	return mapped
+}
+
+// instanceReusePass copies the instances of shared services from the parent container,
+// if the services and all their dependencies are defined the same way in both containers.
+type instanceReusePass struct {
+	parent    *Container
+	unchanged map[ID]bool
+}
+
+func newInstanceReusePass(parent *Container) *instanceReusePass {
+	return &instanceReusePass{parent: parent}
+}
+
+func (p *instanceReusePass) Run(builder *ContainerBuilder) error {
+	p.unchanged = make(map[ID]bool)
+
+	for scope := range builder.Scopes() {
+		parentScope, ok := p.parent.scopes.Get(scope.name)
+		if !ok {
+			continue
+		}
+		for def := range scope.svcs.Seq() {
+			parentScope.mu.Lock()
+			svc, ok := parentScope.instances[def.ID()]
+			parentScope.mu.Unlock()
+			if ok && def.IsShared() && p.isUnchanged(builder, def) {
+				scope.instances[def.ID()] = svc
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *instanceReusePass) isUnchanged(builder *ContainerBuilder, def *ServiceDefinition) bool {
+	if unchanged, ok := p.unchanged[def.ID()]; ok {
+		return unchanged
+	}
+	p.unchanged[def.ID()] = false // Guards against cycles, in case the cycle validation is disabled.
+
+	unchanged := p.isDefinitionUnchanged(def)
+	if unchanged {
+		for _, id := range ResolveServiceDependencyIDs(def) {
+			dep, ok := builder.ServiceDefinition(id)
+			if !ok || !p.isUnchanged(builder, dep) {
+				unchanged = false
+				break
+			}
+		}
+	}
+
+	p.unchanged[def.ID()] = unchanged
+	return unchanged
+}
+
+func (p *instanceReusePass) isDefinitionUnchanged(def *ServiceDefinition) bool {
+	parentScope, ok := p.parent.scopes.Get(def.scope.name)
+	if !ok {
+		return false
+	}
+	parentDef, ok := parentScope.GetServiceDefinition(def.ID())
+	if !ok {
+		return false
+	}
+
+	if parentDef.IsShared() != def.IsShared() ||
+		parentDef.Factory().fn.source() != def.Factory().fn.source() ||
+		!equalArgLists(parentDef.EffectiveScope(), parentDef.Factory().Args(), def.EffectiveScope(), def.Factory().Args()) {
+		return false
+	}
+
+	if len(parentDef.methodCalls) != len(def.methodCalls) {
+		return false
+	}
+	for name, method := range def.methodCalls {
+		parentMethod, ok := parentDef.methodCalls[name]
+		if !ok || !equalArgLists(parentDef.EffectiveScope(), parentMethod.Args(), def.EffectiveScope(), method.Args()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalArgLists(scopeA *Scope, a *ArgList, scopeB *Scope, b *ArgList) bool {
+	argsA, argsB := a.Slots().Args(), b.Slots().Args()
+	return slices.EqualFunc(argsA, argsB, func(argA, argB Arg) bool {
+		if argA == nil || argB == nil {
+			return argA == nil && argB == nil
+		}
+		return equalArgs(argA, argB) && slices.Equal(ResolveArgIDs(scopeA, argA), ResolveArgIDs(scopeB, argB))
+	})
+}
+
+// equalArgs reports whether the arguments are of the same kind and carry the same values.
+// The services they resolve to are compared separately.
+func equalArgs(a, b Arg) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || a.Type() != b.Type() || a.String() != b.String() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *literalArg:
+		return reflect.DeepEqual(a.v, b.(*literalArg).v)
+	case *compoundArg:
+		return slices.EqualFunc(a.args, b.(*compoundArg).args, equalArgs)
+	case *reversedArg:
+		return equalArgs(a.Arg, b.(*reversedArg).Arg)
+	case *SlottedArg:
+		return equalArgs(a.Arg, b.(*SlottedArg).Arg)
+	default:
+		return true
+	}
+}
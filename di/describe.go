@@ -0,0 +1,173 @@
+package di
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/michalkurzeja/godi/v2/internal/util"
+)
+
+// Describe writes a textual description of the resolved graph of the given scopes to w.
+// Unlike Print, the description contains no IDs and is sorted, so it's stable across runs
+// and suitable for snapshot testing. Each argument is described together with the definitions
+// it resolves to, so the description changes whenever the wiring does, e.g. when an interface
+// starts to resolve to a different implementation.
+func Describe(scopes iter.Seq[*Scope], w io.Writer) {
+	var blocks []string
+	for scope := range scopes {
+		blocks = append(blocks, describeScope(scope))
+	}
+	slices.Sort(blocks)
+	_, _ = io.WriteString(w, strings.Join(blocks, "\n"))
+}
+
+func describeScope(s *Scope) string {
+	var bld strings.Builder
+	_, _ = fmt.Fprintf(&bld, "scope %s\n", scopePath(s))
+
+	bindings := lo.Map(s.GetBindings(), func(b *InterfaceBinding, _ int) string {
+		return fmt.Sprintf("  binding %s -> %s\n", util.Signature(b.Interface()), describeTargets(s, b.BoundTo()))
+	})
+	slices.Sort(bindings)
+	for _, binding := range bindings {
+		bld.WriteString(binding)
+	}
+
+	var defs []string
+	for def := range s.ServiceDefinitionsSeq() {
+		defs = append(defs, describeService(def))
+	}
+	for def := range s.FunctionDefinitionsSeq() {
+		defs = append(defs, describeFunction(def))
+	}
+	slices.Sort(defs)
+	for _, def := range defs {
+		bld.WriteString(def)
+	}
+
+	return bld.String()
+}
+
+func describeService(def *ServiceDefinition) string {
+	var bld strings.Builder
+	_, _ = fmt.Fprintf(&bld, "  service %s\n", util.Signature(def.Type()))
+	if def.Name() != "" {
+		_, _ = fmt.Fprintf(&bld, "    name: %s\n", def.Name())
+	}
+	describeLabels(&bld, def.Labels())
+	describeTags(&bld, def.Tags())
+	_, _ = fmt.Fprintf(&bld, "    factory: %s\n", def.FactoryName())
+	_, _ = fmt.Fprintf(&bld, "    lazy: %t, shared: %t, autowired: %t\n", def.IsLazy(), def.IsShared(), def.IsAutowired())
+	if def.Priority() != 0 {
+		_, _ = fmt.Fprintf(&bld, "    priority: %d\n", def.Priority())
+	}
+	if !def.ErrorPolicy().IsZero() {
+		_, _ = fmt.Fprintf(&bld, "    on error: %s\n", def.ErrorPolicy())
+	}
+	if def.ChildScope() != nil {
+		_, _ = fmt.Fprintf(&bld, "    child scope: %s\n", scopePath(def.ChildScope()))
+	}
+	describeArgs(&bld, "    ", def.EffectiveScope(), def.Factory().Args().Slots())
+
+	methods := slices.Clone(def.MethodCalls())
+	slices.SortFunc(methods, func(a, b *Method) int { return strings.Compare(a.Name(), b.Name()) })
+	for _, method := range methods {
+		_, _ = fmt.Fprintf(&bld, "    method %s\n", method.Name())
+		describeArgs(&bld, "      ", def.EffectiveScope(), method.Args().Slots()[1:]) // Skip the receiver.
+	}
+
+	return bld.String()
+}
+
+func describeFunction(def *FunctionDefinition) string {
+	var bld strings.Builder
+	_, _ = fmt.Fprintf(&bld, "  function %s\n", util.Signature(def.Type()))
+	describeLabels(&bld, def.Labels())
+	describeTags(&bld, def.Tags())
+	_, _ = fmt.Fprintf(&bld, "    func: %s\n", def.Func().Name())
+	_, _ = fmt.Fprintf(&bld, "    lazy: %t, autowired: %t\n", def.IsLazy(), def.IsAutowired())
+	if def.ChildScope() != nil {
+		_, _ = fmt.Fprintf(&bld, "    child scope: %s\n", scopePath(def.ChildScope()))
+	}
+	describeArgs(&bld, "    ", def.EffectiveScope(), def.Func().Args().Slots())
+	return bld.String()
+}
+
+func describeLabels(bld *strings.Builder, labels []Label) {
+	if len(labels) == 0 {
+		return
+	}
+	strs := lo.Map(labels, func(l Label, _ int) string { return l.String() })
+	slices.Sort(strs)
+	_, _ = fmt.Fprintf(bld, "    labels: %s\n", strings.Join(strs, ", "))
+}
+
+func describeTags(bld *strings.Builder, tags []Tag) {
+	if len(tags) == 0 {
+		return
+	}
+	strs := lo.Map(tags, func(t Tag, _ int) string { return t.String() })
+	slices.Sort(strs)
+	_, _ = fmt.Fprintf(bld, "    tags: %s\n", strings.Join(strs, "; "))
+}
+
+func describeArgs(bld *strings.Builder, indent string, scope *Scope, slots []*Slot) {
+	for _, slot := range slots {
+		if !slot.IsFilled() {
+			_, _ = fmt.Fprintf(bld, "%sarg %d %s: <not set>\n", indent, slot.Index(), util.Signature(slot.Type()))
+			continue
+		}
+		_, _ = fmt.Fprintf(bld, "%sarg %d %s -> %s\n", indent, slot.Index(), util.Signature(slot.Type()), describeTargets(scope, slot.Arg()))
+	}
+}
+
+// describeTargets describes what the argument resolves to: either a literal value, or a list of definitions.
+// The definitions are kept in the order of resolution, because it's the order in which they are injected.
+func describeTargets(scope *Scope, arg Arg) string {
+	if lit, ok := arg.(*literalArg); ok {
+		return describeLiteral(lit)
+	}
+
+	ids := ResolveArgIDs(scope, arg)
+	if len(ids) == 0 {
+		return "<nothing>"
+	}
+	targets := lo.Map(ids, func(id ID, _ int) string {
+		def, ok := scope.GetServiceDefinitionInChain(id)
+		if !ok {
+			return "<unknown>"
+		}
+		return fmt.Sprintf("%s (factory: %s, scope: %s)", util.Signature(def.Type()), def.FactoryName(), scopePath(def.Scope()))
+	})
+	return strings.Join(targets, ", ")
+}
+
+// describeLiteral only renders the values of basic types, other values (e.g. pointers) are not stable across runs.
+func describeLiteral(a *literalArg) string {
+	if a.v == nil {
+		return "literal nil"
+	}
+	switch reflect.TypeOf(a.v).Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return fmt.Sprintf("literal %#v", a.v)
+	default:
+		return fmt.Sprintf("literal of type %s", util.Signature(reflect.TypeOf(a.v)))
+	}
+}
+
+func scopePath(s *Scope) string {
+	if s == nil {
+		return "<none>"
+	}
+	path := lo.Map(slices.Collect(s.Chain()), func(s *Scope, _ int) string { return s.Name() })
+	slices.Reverse(path)
+	return strings.Join(path, "/")
+}
@@ -0,0 +1,269 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/michalkurzeja/godi/v2/internal/iterx"
+)
+
+// frozenMsg explains why a frozen object cannot be modified.
+const frozenMsg = "it is frozen, as its container is already built"
+
+// freeze makes the definitions, scopes and bindings of the container immutable.
+// Any attempt to modify them afterwards panics, as it could corrupt the indexes
+// of the definition registries and the state of the running container.
+func (c *Container) freeze() {
+	for scope := range iterx.Values(c.scopes.Iterator()) {
+		scope.frozen = true
+		scope.svcs.frozen = true
+		scope.funs.frozen = true
+		for def := range scope.svcs.Seq() {
+			def.freeze()
+		}
+		for def := range scope.funs.Seq() {
+			def.freeze()
+		}
+	}
+}
+
+// IsFrozen reports whether the definition is frozen. Definitions are frozen once their container is built.
+func (d *ServiceDefinition) IsFrozen() bool {
+	return d.frozen
+}
+
+func (d *ServiceDefinition) freeze() {
+	d.frozen = true
+	if d.factory != nil {
+		d.factory.Args().freeze()
+	}
+	for _, method := range d.methodCalls {
+		method.Args().freeze()
+	}
+}
+
+func (d *ServiceDefinition) mustNotBeFrozen() {
+	if d.frozen {
+		panic(fmt.Sprintf("cannot modify service definition %s: %s", d, frozenMsg))
+	}
+}
+
+// IsFrozen reports whether the definition is frozen. Definitions are frozen once their container is built.
+func (d *FunctionDefinition) IsFrozen() bool {
+	return d.frozen
+}
+
+func (d *FunctionDefinition) freeze() {
+	d.frozen = true
+	if d.function != nil {
+		d.function.Args().freeze()
+	}
+}
+
+func (d *FunctionDefinition) mustNotBeFrozen() {
+	if d.frozen {
+		panic(fmt.Sprintf("cannot modify function definition %s: %s", d, frozenMsg))
+	}
+}
+
+// IsFrozen reports whether the scope is frozen. Scopes are frozen once their container is built.
+func (s *Scope) IsFrozen() bool {
+	return s.frozen
+}
+
+func (s *Scope) mustNotBeFrozen() {
+	if s.frozen {
+		panic(fmt.Sprintf("cannot modify scope %s: %s", s, frozenMsg))
+	}
+}
+
+func (r *DefinitionRegistry[Def]) mustNotBeFrozen() {
+	if r.frozen {
+		panic("cannot modify definition registry: " + frozenMsg)
+	}
+}
+
+func (l *ArgList) freeze() {
+	for _, slot := range l.slots {
+		slot.frozen = true
+	}
+}
+
+func (s *Slot) mustNotBeFrozen() {
+	if s.frozen {
+		panic(fmt.Sprintf("cannot modify argument slot %d: %s", s.i, frozenMsg))
+	}
+}
+
+// ServiceDefinitionView is a read-only view of a service definition, for inspection of a built container.
+type ServiceDefinitionView struct {
+	def *ServiceDefinition
+}
+
+func (v ServiceDefinitionView) ID() ID {
+	return v.def.ID()
+}
+
+func (v ServiceDefinitionView) Type() reflect.Type {
+	return v.def.Type()
+}
+
+func (v ServiceDefinitionView) Name() string {
+	return v.def.Name()
+}
+
+func (v ServiceDefinitionView) Key() string {
+	return v.def.Key()
+}
+
+func (v ServiceDefinitionView) Labels() []Label {
+	return slices.Clone(v.def.Labels())
+}
+
+func (v ServiceDefinitionView) Tags() []Tag {
+	return cloneTags(v.def.Tags())
+}
+
+func (v ServiceDefinitionView) Priority() int {
+	return v.def.Priority()
+}
+
+func (v ServiceDefinitionView) FactoryName() string {
+	return v.def.FactoryName()
+}
+
+// MethodNames returns the names of the methods called on the service after its instantiation.
+func (v ServiceDefinitionView) MethodNames() []string {
+	names := make([]string, 0, len(v.def.methodCalls))
+	for _, method := range v.def.MethodCalls() {
+		names = append(names, method.Name())
+	}
+	return names
+}
+
+// ScopeName returns the name of the scope that the service is defined in.
+func (v ServiceDefinitionView) ScopeName() string {
+	return v.def.Scope().Name()
+}
+
+// Dependencies returns the IDs of the services that the service depends on.
+func (v ServiceDefinitionView) Dependencies() []ID {
+	return ResolveServiceDependencyIDs(v.def)
+}
+
+func (v ServiceDefinitionView) IsLazy() bool {
+	return v.def.IsLazy()
+}
+
+func (v ServiceDefinitionView) IsShared() bool {
+	return v.def.IsShared()
+}
+
+func (v ServiceDefinitionView) IsAutowired() bool {
+	return v.def.IsAutowired()
+}
+
+func (v ServiceDefinitionView) ErrorPolicy() ErrorPolicy {
+	return v.def.ErrorPolicy()
+}
+
+func (v ServiceDefinitionView) String() string {
+	return v.def.String()
+}
+
+// FunctionDefinitionView is a read-only view of a function definition, for inspection of a built container.
+type FunctionDefinitionView struct {
+	def *FunctionDefinition
+}
+
+func (v FunctionDefinitionView) ID() ID {
+	return v.def.ID()
+}
+
+func (v FunctionDefinitionView) Type() reflect.Type {
+	return v.def.Type()
+}
+
+func (v FunctionDefinitionView) Name() string {
+	return v.def.Func().Name()
+}
+
+func (v FunctionDefinitionView) Labels() []Label {
+	return slices.Clone(v.def.Labels())
+}
+
+func (v FunctionDefinitionView) Tags() []Tag {
+	return cloneTags(v.def.Tags())
+}
+
+// ScopeName returns the name of the scope that the function is defined in.
+func (v FunctionDefinitionView) ScopeName() string {
+	return v.def.Scope().Name()
+}
+
+// Dependencies returns the IDs of the services that the function depends on.
+func (v FunctionDefinitionView) Dependencies() []ID {
+	return ResolveFunctionDependencyIDs(v.def)
+}
+
+func (v FunctionDefinitionView) IsLazy() bool {
+	return v.def.IsLazy()
+}
+
+func (v FunctionDefinitionView) IsAutowired() bool {
+	return v.def.IsAutowired()
+}
+
+func (v FunctionDefinitionView) String() string {
+	return v.def.String()
+}
+
+// ServiceDefinitions returns read-only views of the service definitions of all scopes of the container.
+func (c *Container) ServiceDefinitions() []ServiceDefinitionView {
+	var views []ServiceDefinitionView
+	for scope := range iterx.Values(c.scopes.Iterator()) {
+		for def := range scope.svcs.Seq() {
+			views = append(views, ServiceDefinitionView{def: def})
+		}
+	}
+	return views
+}
+
+// ServiceDefinition returns a read-only view of the service definition with the given ID, regardless of its scope.
+func (c *Container) ServiceDefinition(id ID) (ServiceDefinitionView, bool) {
+	def, ok := c.serviceDefinition(id)
+	if !ok {
+		return ServiceDefinitionView{}, false
+	}
+	return ServiceDefinitionView{def: def}, true
+}
+
+// FunctionDefinitions returns read-only views of the function definitions of all scopes of the container.
+func (c *Container) FunctionDefinitions() []FunctionDefinitionView {
+	var views []FunctionDefinitionView
+	for scope := range iterx.Values(c.scopes.Iterator()) {
+		for def := range scope.funs.Seq() {
+			views = append(views, FunctionDefinitionView{def: def})
+		}
+	}
+	return views
+}
+
+// FunctionDefinition returns a read-only view of the function definition with the given ID, regardless of its scope.
+func (c *Container) FunctionDefinition(id ID) (FunctionDefinitionView, bool) {
+	for scope := range iterx.Values(c.scopes.Iterator()) {
+		if def, ok := scope.GetFunctionDefinition(id); ok {
+			return FunctionDefinitionView{def: def}, true
+		}
+	}
+	return FunctionDefinitionView{}, false
+}
+
+func viewsOf(defs []*ServiceDefinition) []ServiceDefinitionView {
+	views := make([]ServiceDefinitionView, len(defs))
+	for i, def := range defs {
+		views[i] = ServiceDefinitionView{def: def}
+	}
+	return views
+}
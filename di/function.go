@@ -1,6 +1,7 @@
 package di
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -48,7 +49,11 @@ func NewFactory(fn any, args ...Arg) (*Factory, error) {
 }
 
 func (f *Factory) Execute(scope *Scope) (any, error) {
-	out, err := f.fn.Execute(scope)
+	return f.execute(newResolution(context.Background()), scope)
+}
+
+func (f *Factory) execute(res resolution, scope *Scope) (any, error) {
+	out, err := f.fn.execute(res, scope, reflect.Value{})
 	if err != nil {
 		return nil, errorsx.Wrap(err, "failed to execute factory")
 	}
@@ -111,7 +116,20 @@ func NewMethod(fn any, receiver Arg, args ...Arg) (*Method, error) {
 }
 
 func (m *Method) Execute(scope *Scope) error {
-	out, err := m.fn.Execute(scope)
+	return m.execute(newResolution(context.Background()), scope, reflect.Value{})
+}
+
+// executeOn executes the method on the given instance of the service, if the receiver of the method references it.
+// The instance is cached only after all its method calls succeed, so the receiver cannot be resolved from the scope.
+func (m *Method) executeOn(res resolution, scope *Scope, def *ServiceDefinition, svc any) error {
+	if ref, ok := m.fn.args.Slots()[0].Arg().(*refArg); ok && ref.def == def {
+		return m.execute(res, scope, reflect.ValueOf(svc))
+	}
+	return m.execute(res, scope, reflect.Value{})
+}
+
+func (m *Method) execute(res resolution, scope *Scope, receiver reflect.Value) error {
+	out, err := m.fn.execute(res, scope, receiver)
 	if err != nil {
 		return errorsx.Wrap(err, "failed to execute method")
 	}
@@ -143,6 +161,8 @@ type Func struct {
 	args    *ArgList
 	returns []reflect.Type
 	name    string
+	plan    *plan // Nil until compiled by the resolution planning pass.
+	origin  *Func // The function this one is a copy of (see Container.DerivedBuilder), or nil.
 }
 
 func NewFunc(fn reflect.Value, args ...Arg) (*Func, error) {
@@ -168,7 +188,18 @@ func NewFunc(fn reflect.Value, args ...Arg) (*Func, error) {
 	return f, nil
 }
 
+// Execute resolves the arguments and calls the function.
+// If the function has a resolution plan for the scope, the plan is used instead of resolving the arguments one by one.
 func (f *Func) Execute(scope *Scope) ([]reflect.Value, error) {
+	return f.execute(newResolution(context.Background()), scope, reflect.Value{})
+}
+
+// execute is like Execute, but if the receiver is valid, it's passed as the first argument instead of the resolved one.
+func (f *Func) execute(res resolution, scope *Scope, receiver reflect.Value) ([]reflect.Value, error) {
+	if f.plan != nil && f.plan.scope == scope {
+		return f.executePlan(res, receiver)
+	}
+
 	args, err := f.args.ValidateAndCollect()
 	if err != nil {
 		// This should never happen under normal circumstances - the built-in compiler passes verify args.
@@ -177,22 +208,37 @@ func (f *Func) Execute(scope *Scope) ([]reflect.Value, error) {
 
 	resolvedArgs := make([]reflect.Value, len(args))
 	for i, arg := range args {
-		val, err := ResolveArg(scope, arg)
+		if i == 0 && receiver.IsValid() {
+			resolvedArgs[i] = receiver
+			continue
+		}
+		val, err := resolver.resolve(res, scope, arg)
 		if err != nil {
 			return nil, errorsx.Wrapf(err, "failed to resolve argument %d", i)
 		}
-		resolvedArgs[i] = reflect.ValueOf(val)
+		resolvedArgs[i] = f.argValue(i, val)
 	}
 
 	call := lo.Ternary(f.args.IsVariadic(), f.fn.CallSlice, f.fn.Call)
 	return call(resolvedArgs), nil
 }
 
+// argValue returns the value of the i-th argument. Nil (e.g. a nil interface returned by a factory)
+// is passed as the zero value of the parameter, because reflect can't call a function with an invalid value.
+func (f *Func) argValue(i int, val any) reflect.Value {
+	if val == nil {
+		return reflect.Zero(f.fn.Type().In(i))
+	}
+	return reflect.ValueOf(val)
+}
+
 func (f *Func) Args() *ArgList {
 	return f.args
 }
 
 func (f *Func) AddArgs(args ...Arg) error {
+	f.plan = nil // The arguments change, so the plan is outdated.
+
 	var joinedErrs error
 
 	for _, arg := range args {
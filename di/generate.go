@@ -0,0 +1,771 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"iter"
+	"maps"
+	"math"
+	pathpkg "path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/samber/lo"
+
+	"github.com/michalkurzeja/godi/v2/internal/errorsx"
+	"github.com/michalkurzeja/godi/v2/internal/util"
+)
+
+// GenerateConfig configures Generate.
+type GenerateConfig struct {
+	// PkgName is the name of the package of the generated code.
+	PkgName string
+	// PkgPath is the import path of the package of the generated code.
+	// Its identifiers are referenced without a qualifier, so they don't have to be exported.
+	PkgPath string
+	// TypeName is the name of the generated container type. Defaults to "Container".
+	TypeName string
+	// Source describes where the definitions come from (e.g. the wiring variable), for the doc comments.
+	Source string
+	// BuildConstraint is an optional build constraint expression of the generated file, e.g. "!tag".
+	BuildConstraint string
+}
+
+// Generate writes Go code that constructs the services of the given scopes with direct calls,
+// i.e. without reflection and without any lookups at runtime. The scopes must be compiled,
+// so that all arguments are resolvable.
+//
+// The generated type has a getter for each service, that instantiates it on the first call if the service
+// is shared, or on every call otherwise, so the semantics of shared, lazy and eager services, method calls
+// and child scopes are kept. The services and functions of the root scope get exported accessors, named after
+// the name of the service (see ServiceDefinition.SetName) or its type, and after the function, respectively.
+// Services with ambiguous accessor names get no accessors.
+// Its constructor initialises the eager services and executes the eager functions.
+// The accessors are safe for concurrent use: a mutex of the container serialises the retrievals of services,
+// so that each shared service is instantiated once. Functions are executed outside of it.
+// Interceptors are not supported: the generated code does not call them.
+//
+// Generation fails for definitions that cannot be expressed as plain Go code: factories, methods and functions
+// that are closures, generic or unexported from another package, literal arguments of non-basic types,
+// and services with an ErrorPolicy.
+func Generate(scopes iter.Seq[*Scope], w io.Writer, conf GenerateConfig) error {
+	if conf.TypeName == "" {
+		conf.TypeName = "Container"
+	}
+	g := &generator{
+		conf:    conf,
+		svcs:    make(map[ID]int),
+		funs:    make(map[ID]int),
+		imports: make(map[string]string),
+		aliases: make(map[string]bool),
+		std:     make(map[string]bool),
+	}
+	for _, name := range []string{"c", "svc", "err", "fmt", "slices", "sync", conf.PkgName} {
+		g.aliases[name] = true // Reserved identifiers of the generated code.
+	}
+
+	var svcs []*ServiceDefinition
+	var funs []*FunctionDefinition
+	for scope := range scopes {
+		for def := range scope.ServiceDefinitionsSeq() {
+			g.svcs[def.ID()] = len(svcs)
+			svcs = append(svcs, def)
+		}
+		for def := range scope.FunctionDefinitionsSeq() {
+			g.funs[def.ID()] = len(funs)
+			funs = append(funs, def)
+		}
+	}
+
+	var joinedErrs error
+	var decls []string
+	for i, def := range svcs {
+		decl, err := g.service(i, def)
+		if err != nil {
+			joinedErrs = errors.Join(joinedErrs, errorsx.Wrapf(err, "cannot generate service %s", def))
+			continue
+		}
+		decls = append(decls, decl)
+	}
+	for i, def := range funs {
+		decl, err := g.function(i, def)
+		if err != nil {
+			joinedErrs = errors.Join(joinedErrs, errorsx.Wrapf(err, "cannot generate function %s", def))
+			continue
+		}
+		decls = append(decls, decl)
+	}
+	if joinedErrs != nil {
+		return joinedErrs
+	}
+
+	var body strings.Builder
+	g.containerType(&body, svcs)
+	g.constructor(&body, svcs, funs)
+	g.accessors(&body, svcs, funs)
+	for _, decl := range decls {
+		body.WriteString(decl)
+	}
+
+	var bld strings.Builder
+	g.header(&bld) // Written last, as it depends on the imports used by the body.
+	bld.WriteString(body.String())
+
+	src, err := format.Source([]byte(bld.String()))
+	if err != nil {
+		return errorsx.Wrap(err, "failed to format the generated code")
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+type generator struct {
+	conf GenerateConfig
+
+	svcs map[ID]int // Indexes of the getters of services.
+	funs map[ID]int // Indexes of the executors of functions.
+
+	imports map[string]string // Aliases by import path.
+	aliases map[string]bool
+	std     map[string]bool // Standard library packages used by the generated code.
+}
+
+func (g *generator) header(bld *strings.Builder) {
+	if g.conf.BuildConstraint != "" {
+		_, _ = fmt.Fprintf(bld, "//go:build %s\n\n", g.conf.BuildConstraint)
+	}
+	bld.WriteString("// Code generated by godi-gen. DO NOT EDIT.\n\n")
+	_, _ = fmt.Fprintf(bld, "package %s\n\n", g.conf.PkgName)
+
+	if len(g.std) == 0 && len(g.imports) == 0 {
+		return
+	}
+	// Standard library packages go first, like goimports does.
+	var std, other []string
+	for path := range g.std {
+		std = append(std, strconv.Quote(path))
+	}
+	for _, path := range slices.Sorted(maps.Keys(g.imports)) {
+		spec := strconv.Quote(path)
+		if alias := g.imports[path]; alias != pathpkg.Base(path) {
+			spec = alias + " " + spec
+		}
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			other = append(other, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	slices.Sort(std)
+	bld.WriteString("import (\n")
+	for _, spec := range std {
+		_, _ = fmt.Fprintf(bld, "\t%s\n", spec)
+	}
+	bld.WriteString("\n")
+	for _, spec := range other {
+		_, _ = fmt.Fprintf(bld, "\t%s\n", spec)
+	}
+	bld.WriteString(")\n\n")
+}
+
+// errorf returns a call of fmt.Errorf, that wraps err with the given message.
+func (g *generator) errorf(msg string, args ...string) string {
+	g.std["fmt"] = true
+	return fmt.Sprintf("fmt.Errorf(%q, %s)", msg+": %w", strings.Join(append(args, "err"), ", "))
+}
+
+func (g *generator) containerType(bld *strings.Builder, svcs []*ServiceDefinition) {
+	_, _ = fmt.Fprintf(bld, "// %s constructs the services", g.conf.TypeName)
+	if g.conf.Source != "" {
+		_, _ = fmt.Fprintf(bld, " defined in %s", g.conf.Source)
+	}
+	bld.WriteString(" with direct calls.\n")
+	_, _ = fmt.Fprintf(bld, "type %s struct {\n", g.conf.TypeName)
+	g.std["sync"] = true
+	bld.WriteString("\tmu sync.Mutex // Guards the instances. The unexported getters must be called with it held.\n\n")
+	for i, def := range svcs {
+		if !def.IsShared() {
+			continue
+		}
+		typ, _ := g.typeExpr(def.Type()) // Already checked by the getter.
+		_, _ = fmt.Fprintf(bld, "\tinstance%d   %s\n\tinstance%dOK bool\n", i, typ, i)
+	}
+	bld.WriteString("}\n\n")
+}
+
+func (g *generator) constructor(bld *strings.Builder, svcs []*ServiceDefinition, funs []*FunctionDefinition) {
+	_, _ = fmt.Fprintf(bld, "// New%s creates the container and initialises its eager services and functions.\n", g.conf.TypeName)
+	_, _ = fmt.Fprintf(bld, "func New%s() (*%s, error) {\n", g.conf.TypeName, g.conf.TypeName)
+	_, _ = fmt.Fprintf(bld, "\tc := &%s{}\n", g.conf.TypeName)
+	for i, def := range svcs {
+		if def.IsLazy() {
+			continue
+		}
+		_, _ = fmt.Fprintf(bld, "\tif _, err := c.svc%d(); err != nil {\n", i)
+		_, _ = fmt.Fprintf(bld, "\t\treturn nil, %s\n\t}\n", g.errorf("failed to initialise eager service %s", strconv.Quote(def.String())))
+	}
+	for i, def := range funs {
+		if def.IsLazy() {
+			continue
+		}
+		blanks := strings.Repeat("_, ", def.Type().NumOut())
+		_, _ = fmt.Fprintf(bld, "\tif %serr := c.fn%d(); err != nil {\n", blanks, i)
+		_, _ = fmt.Fprintf(bld, "\t\treturn nil, %s\n\t}\n", g.errorf("failed to execute eager function %s", strconv.Quote(def.String())))
+	}
+	bld.WriteString("\treturn c, nil\n}\n\n")
+}
+
+// accessors writes the exported accessors of the services and functions of the root scope.
+func (g *generator) accessors(bld *strings.Builder, svcs []*ServiceDefinition, funs []*FunctionDefinition) {
+	taken := make(map[string]int)
+	svcNames := make(map[int]string)
+	for i, def := range svcs {
+		if def.Scope().Parent() != nil {
+			continue
+		}
+		if name := accessorName(def); name != "" {
+			svcNames[i] = name
+			taken[name]++
+		}
+	}
+	funNames := make(map[int]string)
+	for i, def := range funs {
+		if def.Scope().Parent() != nil {
+			continue
+		}
+		name := exportedIdent(util.FuncNameShort(def.Func().fn))
+		funNames[i] = name
+		taken[name]++
+	}
+
+	for i, def := range svcs {
+		name, ok := svcNames[i]
+		if !ok || taken[name] > 1 {
+			continue
+		}
+		typ, _ := g.typeExpr(def.Type())
+		_, _ = fmt.Fprintf(bld, "// %s returns the service %s.\n", name, def)
+		_, _ = fmt.Fprintf(bld, "func (c *%s) %s() (%s, error) {\n\tc.mu.Lock()\n\tdefer c.mu.Unlock()\n\treturn c.svc%d()\n}\n\n", g.conf.TypeName, name, typ, i)
+	}
+	for i, def := range funs {
+		name, ok := funNames[i]
+		if !ok || taken[name] > 1 {
+			continue
+		}
+		results, _ := g.results(def.Type())
+		_, _ = fmt.Fprintf(bld, "// %s executes the function %s.\n", name, def)
+		_, _ = fmt.Fprintf(bld, "func (c *%s) %s() (%s) {\n\treturn c.fn%d()\n}\n\n", g.conf.TypeName, name, strings.Join(append(results, "error"), ", "), i)
+	}
+}
+
+// accessorName returns the name of the accessor of the service, or an empty string if it has none.
+func accessorName(def *ServiceDefinition) string {
+	if def.Name() != "" {
+		return exportedIdent(def.Name())
+	}
+	typ := def.Type()
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.PkgPath() == "" || strings.Contains(typ.Name(), "[") {
+		return "" // Built-in types and instantiated generic types have no meaningful names.
+	}
+	return exportedIdent(typ.Name())
+}
+
+func exportedIdent(name string) string {
+	var bld strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		bld.WriteRune(r)
+	}
+	ident := bld.String()
+	if ident == "" || unicode.IsDigit(rune(ident[0])) {
+		ident = "Svc" + ident
+	}
+	return ident
+}
+
+func (g *generator) service(i int, def *ServiceDefinition) (string, error) {
+	if !def.ErrorPolicy().IsZero() {
+		return "", fmt.Errorf("error policy (%s) is not supported", def.ErrorPolicy())
+	}
+	typ, err := g.typeExpr(def.Type())
+	if err != nil {
+		return "", err
+	}
+	factory, err := g.funcIdent(def.Factory().fn.fn)
+	if err != nil {
+		return "", errorsx.Wrap(err, "invalid factory")
+	}
+
+	f := &funcWriter{onErr: "return *new(" + typ + "), %s"}
+	_, _ = fmt.Fprintf(&f.bld, "// svc%d returns the service %s (scope: %s).\n", i, def, scopePath(def.Scope()))
+	_, _ = fmt.Fprintf(&f.bld, "func (c *%s) svc%d() (%s, error) {\n", g.conf.TypeName, i, typ)
+	if def.IsShared() {
+		_, _ = fmt.Fprintf(&f.bld, "\tif c.instance%dOK {\n\t\treturn c.instance%d, nil\n\t}\n", i, i)
+	}
+
+	args, err := g.args(f, def.EffectiveScope(), def.Factory().Args())
+	if err != nil {
+		return "", errorsx.Wrap(err, "invalid factory arguments")
+	}
+	call := fmt.Sprintf("%s(%s)", factory, args)
+	if def.Factory().returnsErr {
+		f.line("svc, err := %s", call)
+		f.line("if err != nil {")
+		f.line("\t"+f.onErr, g.errorf("failed to execute factory for service %s", strconv.Quote(def.String())))
+		f.line("}")
+	} else {
+		f.line("svc := %s", call)
+	}
+	if def.IsShared() {
+		f.line("c.instance%d, c.instance%dOK = svc, true", i, i)
+	}
+
+	methods := def.MethodCalls()
+	slices.SortFunc(methods, func(a, b *Method) int { return strings.Compare(a.Name(), b.Name()) })
+	for _, method := range methods {
+		name := util.FuncNameShort(method.fn.fn)
+		if !token.IsIdentifier(name) {
+			return "", fmt.Errorf("method %s is not a method expression, e.g. (*T).Method", method)
+		}
+		args, err := g.args(f, def.EffectiveScope(), method.Args(), 1) // The receiver is svc.
+		if err != nil {
+			return "", errorsx.Wrapf(err, "invalid arguments of method %s", method)
+		}
+		call := fmt.Sprintf("svc.%s(%s)", name, args)
+		if method.returnsErr {
+			f.line("if err := %s; err != nil {", call)
+			f.line("\t"+f.onErr, g.errorf("failed to execute method %s of service %s", strconv.Quote(method.String()), strconv.Quote(def.String())))
+			f.line("}")
+		} else {
+			f.line("%s", call)
+		}
+	}
+
+	f.line("return svc, nil")
+	f.bld.WriteString("}\n\n")
+	return f.bld.String(), nil
+}
+
+func (g *generator) function(i int, def *FunctionDefinition) (string, error) {
+	fn, err := g.funcIdent(def.Func().fn)
+	if err != nil {
+		return "", err
+	}
+	results, err := g.results(def.Type())
+	if err != nil {
+		return "", err
+	}
+
+	names := lo.Times(len(results), func(i int) string { return fmt.Sprintf("r%d", i) })
+	// The arguments are resolved with the mutex held, but the function is executed without it.
+	f := &funcWriter{onErr: "c.mu.Unlock()\nreturn " + strings.Join(append(slices.Clone(names), "%s"), ", ")}
+	namedResults := lo.Map(results, func(typ string, i int) string { return names[i] + " " + typ })
+	_, _ = fmt.Fprintf(&f.bld, "// fn%d executes the function %s (scope: %s).\n", i, def, scopePath(def.Scope()))
+	_, _ = fmt.Fprintf(&f.bld, "func (c *%s) fn%d() (%s) {\n", g.conf.TypeName, i, strings.Join(append(namedResults, "err error"), ", "))
+
+	f.line("c.mu.Lock()")
+	args, err := g.args(f, def.EffectiveScope(), def.Func().Args())
+	if err != nil {
+		return "", errorsx.Wrap(err, "invalid arguments")
+	}
+	f.line("c.mu.Unlock()")
+	call := fmt.Sprintf("%s(%s)", fn, args)
+	if len(names) > 0 {
+		f.line("%s = %s", strings.Join(names, ", "), call)
+	} else {
+		f.line("%s", call)
+	}
+	f.line("return %s", strings.Join(append(names, "nil"), ", "))
+	f.bld.WriteString("}\n\n")
+	return f.bld.String(), nil
+}
+
+func (g *generator) results(fnType reflect.Type) ([]string, error) {
+	results := make([]string, fnType.NumOut())
+	for i := range fnType.NumOut() {
+		typ, err := g.typeExpr(fnType.Out(i))
+		if err != nil {
+			return nil, err
+		}
+		results[i] = typ
+	}
+	return results, nil
+}
+
+// funcWriter writes the body of a generated function.
+type funcWriter struct {
+	bld   strings.Builder
+	vars  int
+	onErr string // The return statement on error, with a %s verb for the error.
+}
+
+func (f *funcWriter) line(format string, args ...any) {
+	_, _ = fmt.Fprintf(&f.bld, "\t"+format+"\n", args...)
+}
+
+func (f *funcWriter) newVar() string {
+	f.vars++
+	return fmt.Sprintf("v%d", f.vars)
+}
+
+// args writes the statements that resolve the arguments and returns the argument list of the call.
+func (g *generator) args(f *funcWriter, scope *Scope, list *ArgList, skip ...int) (string, error) {
+	args, err := list.ValidateAndCollect()
+	if err != nil {
+		return "", err
+	}
+	args = args[lo.Sum(skip):]
+
+	exprs := make([]string, len(args))
+	var joinedErrs error
+	for i, arg := range args {
+		expr, err := g.arg(f, scope, arg)
+		if err != nil {
+			joinedErrs = errors.Join(joinedErrs, errorsx.Wrapf(err, "argument %d", i))
+			continue
+		}
+		exprs[i] = expr
+	}
+	if joinedErrs != nil {
+		return "", joinedErrs
+	}
+
+	if list.IsVariadic() && len(exprs) > 0 {
+		exprs[len(exprs)-1] += "..."
+	}
+	return strings.Join(exprs, ", "), nil
+}
+
+// arg writes the statements that resolve the argument and returns the expression of its value.
+// It mirrors the ArgResolver.
+func (g *generator) arg(f *funcWriter, scope *Scope, arg Arg) (string, error) {
+	switch a := arg.(type) {
+	case *SlottedArg:
+		return g.arg(f, scope, a.Arg)
+	case *literalArg:
+		return g.literal(reflect.ValueOf(a.v))
+	case *refArg:
+		return g.svc(f, a.def.ID())
+	case *nameArg:
+		return g.single(f, ResolveArgIDs(scope, a), a)
+	case *typeArg:
+		if boundTo, ok := scope.GetBoundArgInChain(a.typ); ok {
+			return g.arg(f, scope, boundTo)
+		}
+		if a.slice {
+			return g.slice(f, a.typ, ResolveArgIDs(scope, a))
+		}
+		return g.single(f, ResolveArgIDs(scope, a), a)
+	case *labelArg:
+		if a.slice {
+			return g.slice(f, a.typ, ResolveArgIDs(scope, a))
+		}
+		return g.single(f, ResolveArgIDs(scope, a), a)
+	case *flexibleSliceArg:
+		return g.flexibleSlice(f, scope, a)
+	case *compoundArg:
+		elems := make([]string, len(a.args))
+		for i, sub := range a.args {
+			expr, err := g.arg(f, scope, sub)
+			if err != nil {
+				return "", err
+			}
+			elems[i] = expr
+		}
+		return g.sliceLiteral(a.typ, elems)
+	case *mapArg:
+		return g.mapArg(f, scope, a)
+	case *reversedArg:
+		expr, err := g.arg(f, scope, a.Arg)
+		if err != nil {
+			return "", err
+		}
+		g.std["slices"] = true
+		v := f.newVar()
+		f.line("%s := slices.Clone(%s)", v, expr)
+		f.line("slices.Reverse(%s)", v)
+		return v, nil
+	default:
+		return "", fmt.Errorf("unsupported arg type %T", arg)
+	}
+}
+
+// svc writes the call of the getter of the service and returns the variable that holds the service.
+func (g *generator) svc(f *funcWriter, id ID) (string, error) {
+	i, ok := g.svcs[id]
+	if !ok {
+		return "", fmt.Errorf("service %s not found", id)
+	}
+	v := f.newVar()
+	f.line("%s, err := c.svc%d()", v, i)
+	f.line("if err != nil {")
+	f.line("\t"+f.onErr, "err")
+	f.line("}")
+	return v, nil
+}
+
+func (g *generator) single(f *funcWriter, ids []ID, arg Arg) (string, error) {
+	if len(ids) != 1 {
+		return "", fmt.Errorf("argument %s resolves to %d services, expected one", arg, len(ids))
+	}
+	return g.svc(f, ids[0])
+}
+
+func (g *generator) slice(f *funcWriter, elemType reflect.Type, ids []ID) (string, error) {
+	elems := make([]string, len(ids))
+	for i, id := range ids {
+		expr, err := g.svc(f, id)
+		if err != nil {
+			return "", err
+		}
+		elems[i] = expr
+	}
+	return g.sliceLiteral(elemType, elems)
+}
+
+func (g *generator) sliceLiteral(elemType reflect.Type, elems []string) (string, error) {
+	typ, err := g.typeExpr(reflect.SliceOf(elemType))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s{%s}", typ, strings.Join(elems, ", ")), nil
+}
+
+func (g *generator) flexibleSlice(f *funcWriter, scope *Scope, a *flexibleSliceArg) (string, error) {
+	if boundTo, ok := scope.GetBoundArgInChain(a.Type()); ok {
+		return g.arg(f, scope, boundTo)
+	}
+	if ids := scope.GetServicesIDsByTypeInChain(a.Type()); len(ids) > 0 {
+		return g.single(f, ids, a)
+	}
+
+	elemType := a.Type().Elem()
+	if boundTo, ok := scope.GetBoundArgInChain(elemType); ok {
+		expr, err := g.arg(f, scope, boundTo)
+		if err != nil {
+			return "", err
+		}
+		return g.sliceLiteral(elemType, []string{expr})
+	}
+	return g.slice(f, elemType, scope.GetServicesIDsByTypeInChain(elemType))
+}
+
+func (g *generator) mapArg(f *funcWriter, scope *Scope, a *mapArg) (string, error) {
+	entries, err := resolver.mapArgResolver.entries(scope, a)
+	if err != nil {
+		return "", err
+	}
+	typ, err := g.typeExpr(a.typ)
+	if err != nil {
+		return "", err
+	}
+	elems := make([]string, len(entries))
+	for i, entry := range entries {
+		key, err := g.literal(entry.key)
+		if err != nil {
+			return "", errorsx.Wrap(err, "invalid map key")
+		}
+		val, err := g.arg(f, scope, entry.arg)
+		if err != nil {
+			return "", err
+		}
+		elems[i] = key + ": " + val
+	}
+	return fmt.Sprintf("%s{%s}", typ, strings.Join(elems, ", ")), nil
+}
+
+// literal returns the expression of a literal value. Only values of basic types and zero values are supported.
+func (g *generator) literal(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "nil", nil
+	}
+	typ, err := g.typeExpr(v.Type())
+	if err != nil {
+		return "", err
+	}
+
+	var lit string
+	switch v.Kind() {
+	case reflect.Bool:
+		lit = strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		lit = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		lit = strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		if math.IsInf(v.Float(), 0) || math.IsNaN(v.Float()) {
+			return "", fmt.Errorf("literal %v cannot be generated", v.Float())
+		}
+		lit = strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.String:
+		lit = strconv.Quote(v.String())
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan, reflect.Interface:
+		if !v.IsNil() {
+			return "", fmt.Errorf("literal of type %s cannot be generated, only basic types and nil values are supported", util.Signature(v.Type()))
+		}
+		return fmt.Sprintf("(%s)(nil)", typ), nil
+	case reflect.Struct, reflect.Array:
+		if !v.IsZero() {
+			return "", fmt.Errorf("literal of type %s cannot be generated, only basic types and zero values are supported", util.Signature(v.Type()))
+		}
+		return fmt.Sprintf("%s{}", typ), nil
+	default:
+		return "", fmt.Errorf("literal of type %s cannot be generated", util.Signature(v.Type()))
+	}
+
+	// Untyped constants of the default types need no conversion.
+	if typ == "string" || typ == "int" || typ == "bool" {
+		return lit, nil
+	}
+	return fmt.Sprintf("%s(%s)", typ, lit), nil
+}
+
+// funcIdent returns the qualified identifier of a top-level function.
+func (g *generator) funcIdent(fn reflect.Value) (string, error) {
+	fullName := util.FuncName(fn)
+	slash := strings.LastIndex(fullName, "/")
+	dot := strings.Index(fullName[slash+1:], ".")
+	if dot < 0 {
+		return "", fmt.Errorf("function %s has no package", fullName)
+	}
+	dot += slash + 1
+	pkgPath := strings.ReplaceAll(fullName[:dot], "%2e", ".") // The linker escapes dots in the last path element.
+	name := fullName[dot+1:]
+
+	if !token.IsIdentifier(name) {
+		return "", fmt.Errorf("function %s is not a top-level function (closures and generic functions are not supported)", fullName)
+	}
+	return g.qualify(pkgPath, name)
+}
+
+// typeExpr returns the expression of the type.
+func (g *generator) typeExpr(typ reflect.Type) (string, error) {
+	if typ.Name() != "" {
+		if typ.PkgPath() == "" {
+			return typ.Name(), nil // A built-in type.
+		}
+		if strings.Contains(typ.Name(), "[") {
+			return "", fmt.Errorf("type %s is an instantiated generic type, which is not supported", util.Signature(typ))
+		}
+		return g.qualify(typ.PkgPath(), typ.Name())
+	}
+
+	elem := func(prefix string, typ reflect.Type) (string, error) {
+		expr, err := g.typeExpr(typ)
+		return prefix + expr, err
+	}
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return elem("*", typ.Elem())
+	case reflect.Slice:
+		return elem("[]", typ.Elem())
+	case reflect.Array:
+		return elem(fmt.Sprintf("[%d]", typ.Len()), typ.Elem())
+	case reflect.Chan:
+		prefix := map[reflect.ChanDir]string{reflect.BothDir: "chan ", reflect.RecvDir: "<-chan ", reflect.SendDir: "chan<- "}[typ.ChanDir()]
+		return elem(prefix, typ.Elem())
+	case reflect.Map:
+		key, err := g.typeExpr(typ.Key())
+		if err != nil {
+			return "", err
+		}
+		return elem("map["+key+"]", typ.Elem())
+	case reflect.Func:
+		var params []string
+		for i := range typ.NumIn() {
+			param, err := g.typeExpr(typ.In(i))
+			if err != nil {
+				return "", err
+			}
+			if typ.IsVariadic() && i == typ.NumIn()-1 {
+				param = "..." + strings.TrimPrefix(param, "[]")
+			}
+			params = append(params, param)
+		}
+		results, err := g.results(typ)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("func(%s) (%s)", strings.Join(params, ", "), strings.Join(results, ", ")), nil
+	case reflect.Interface:
+		if typ.NumMethod() == 0 {
+			return "any", nil
+		}
+	case reflect.Struct:
+		if typ.NumField() == 0 {
+			return "struct{}", nil
+		}
+	default:
+	}
+	return "", fmt.Errorf("unnamed type %s is not supported", typ)
+}
+
+// qualify returns the identifier qualified with the alias of its package, and imports the package.
+func (g *generator) qualify(pkgPath, name string) (string, error) {
+	if pkgPath == g.conf.PkgPath {
+		return name, nil
+	}
+	if !token.IsExported(name) {
+		return "", fmt.Errorf("%s.%s is not exported", pkgPath, name)
+	}
+	if pkgPath == "main" {
+		return "", fmt.Errorf("%s.%s belongs to a main package, which cannot be imported", pkgPath, name)
+	}
+
+	alias, ok := g.imports[pkgPath]
+	if !ok {
+		alias = g.newAlias(pkgPath)
+		g.imports[pkgPath] = alias
+	}
+	return alias + "." + name, nil
+}
+
+// newAlias returns a unique alias for the package, based on the last element of its path.
+func (g *generator) newAlias(pkgPath string) string {
+	elems := strings.Split(pkgPath, "/")
+	base := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersion(base) {
+		base = elems[len(elems)-2]
+	}
+	base = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, base)
+	if base == "" || !token.IsIdentifier(base) || token.IsKeyword(base) {
+		base = "pkg" + base
+	}
+
+	alias := base
+	for i := 2; g.aliases[alias]; i++ {
+		alias = base + strconv.Itoa(i)
+	}
+	g.aliases[alias] = true
+	return alias
+}
+
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(elem[1:])
+	return err == nil
+}
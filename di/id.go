@@ -0,0 +1,65 @@
+package di
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+)
+
+// idNamespace is the namespace of the deterministic IDs.
+var idNamespace = uuid.MustParse("5d3f1a1e-8a4b-4a55-9c37-2f0f6cb2f3c1")
+
+// deterministicIDs replaces the random IDs of definitions with IDs derived from the scope path,
+// the name of the factory (or function) and the index of registration within the scope.
+// The IDs are stable across runs of the same binary, as long as the definitions are registered in the same order.
+type deterministicIDs struct {
+	issued  map[ID]struct{}
+	indexes map[*Scope]int
+}
+
+func newDeterministicIDs() *deterministicIDs {
+	return &deterministicIDs{
+		issued:  make(map[ID]struct{}),
+		indexes: make(map[*Scope]int),
+	}
+}
+
+// clone returns a copy of the generator for a copy of the container, with the scopes mapped to their copies.
+func (g *deterministicIDs) clone(scopes map[*Scope]*Scope) *deterministicIDs {
+	clone := &deterministicIDs{
+		issued:  maps.Clone(g.issued),
+		indexes: make(map[*Scope]int, len(g.indexes)),
+	}
+	for scope, index := range g.indexes {
+		clone.indexes[scopes[scope]] = index
+	}
+	return clone
+}
+
+// next returns the ID for a definition registered in the given scope.
+// A definition that already has a deterministic ID (e.g. it's moved between scopes) keeps it.
+func (g *deterministicIDs) next(scope *Scope, current ID, kind, name string) ID {
+	if _, ok := g.issued[current]; ok {
+		return current
+	}
+
+	index := g.indexes[scope]
+	g.indexes[scope]++
+
+	path := lo.Map(slices.Collect(scope.Chain()), func(s *Scope, _ int) string { return s.Name() })
+	slices.Reverse(path)
+	key := fmt.Sprintf("%s\x00%s\x00%s\x00%d", strings.Join(path, "/"), kind, name, index)
+
+	// Scope names are not guaranteed to be unique, so the key may repeat. Salt it until the ID is unique.
+	id := ID(uuid.NewSHA1(idNamespace, []byte(key)).String())
+	for salt := 1; lo.HasKey(g.issued, id); salt++ {
+		id = ID(uuid.NewSHA1(idNamespace, []byte(fmt.Sprintf("%s\x00%d", key, salt))).String())
+	}
+	g.issued[id] = struct{}{}
+
+	return id
+}
@@ -0,0 +1,216 @@
+package di
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+type CallKind uint8
+
+const (
+	// ServiceInstantiation is a call of a service factory, followed by its method calls.
+	ServiceInstantiation CallKind = iota
+	// MethodCall is a call of a service method, right after the service is created.
+	MethodCall
+	// FunctionCall is an execution of a function.
+	FunctionCall
+	// ServiceCacheHit is a retrieval of an already instantiated shared service.
+	// It does no work, so its duration is meaningless.
+	ServiceCacheHit
+)
+
+func (k CallKind) String() string {
+	switch k {
+	case ServiceInstantiation:
+		return "service instantiation"
+	case MethodCall:
+		return "method call"
+	case FunctionCall:
+		return "function call"
+	case ServiceCacheHit:
+		return "service cache hit"
+	default:
+		return fmt.Sprintf("unknown call kind %d", k)
+	}
+}
+
+// Call describes a call made by the container.
+type Call struct {
+	Kind CallKind
+	// Definition is the definition of the instantiated service or the executed function.
+	// In case of a method call, it's the definition of the service that the method belongs to.
+	Definition Definition
+	// Method is the called method. It's only set for method calls.
+	Method *Method
+	// Scope is the scope that owns the definition.
+	Scope *Scope
+	// ID identifies the call among all calls made by the container.
+	ID uint64
+	// Parent is the ID of the call that triggered this one, e.g. the instantiation of the service that depends
+	// on the service instantiated by this call. It's 0 for the calls made directly by the resolutions.
+	Parent uint64
+	// Context is the context of the resolution that made the call, e.g. the one passed to di.SvcByTypeAsync.
+	// It's context.Background() for resolutions that take no context.
+	Context context.Context
+}
+
+func (c Call) String() string {
+	if c.Kind == MethodCall {
+		return fmt.Sprintf("%s %s of %s", c.Kind, c.Method, c.Definition)
+	}
+	return fmt.Sprintf("%s %s", c.Kind, c.Definition)
+}
+
+// Interceptor observes the calls made by the container.
+// Before is called right before a call, and After right after it, with the duration and the error of the call.
+// The calls can be nested, e.g. a service instantiation triggers the instantiation of its dependencies,
+// but a nested call always finishes before the call that triggered it.
+// Concurrent resolutions (e.g. of di.SvcByTypeAsync) call the interceptors concurrently: the nesting holds
+// within each resolution, and can be followed with Call.ID and Call.Parent.
+// After is called even if the call panics, with an error that says so.
+type Interceptor interface {
+	Before(call Call)
+	After(call Call, d time.Duration, err error)
+}
+
+func (c *Container) AddInterceptors(interceptors ...Interceptor) {
+	c.interceptors = append(c.interceptors, interceptors...)
+}
+
+// intercept makes the call with fn, notifying the interceptors and recording the stats.
+// The nested calls made by fn must use the resolution it's given, so that they know their parent call.
+func (c *Container) intercept(res resolution, call Call, fn func(res resolution) error) (err error) {
+	if len(c.interceptors) == 0 && c.stats == nil {
+		return fn(res)
+	}
+
+	call.ID = c.callIDs.Add(1)
+	call.Parent = res.call
+	call.Context = res.ctx
+	res.call = call.ID
+
+	for _, interceptor := range c.interceptors {
+		interceptor.Before(call)
+	}
+	start := time.Now()
+	completed := false
+	defer func() {
+		if !completed {
+			err = fmt.Errorf("%s panicked", call) // The panic goes on, but the interceptors see the call finish.
+		}
+		d := time.Since(start)
+		if c.stats != nil {
+			c.stats.record(call, d, err)
+		}
+		for _, interceptor := range slices.Backward(c.interceptors) {
+			interceptor.After(call, d, err)
+		}
+	}()
+
+	err = fn(res)
+	completed = true
+	return err
+}
+
+type slogInterceptor struct {
+	logger *slog.Logger
+	level  slog.Level
+}
+
+// NewSlogInterceptor returns an interceptor that logs every call made by the container with the given logger,
+// except for the cache hits. Successful calls are logged on the given level, failed calls are logged as errors.
+func NewSlogInterceptor(logger *slog.Logger, level slog.Level) Interceptor {
+	return &slogInterceptor{logger: logger, level: level}
+}
+
+func (i *slogInterceptor) Before(Call) {}
+
+func (i *slogInterceptor) After(call Call, d time.Duration, err error) {
+	if call.Kind == ServiceCacheHit {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("definition", fmt.Sprint(call.Definition)),
+		slog.String("scope", call.Scope.String()),
+		slog.Duration("duration", d),
+	}
+	if call.Kind == MethodCall {
+		attrs = append(attrs, slog.String("method", call.Method.String()))
+	}
+	if lazy, ok := call.Definition.(interface{ IsLazy() bool }); ok {
+		attrs = append(attrs, slog.Bool("lazy", lazy.IsLazy()))
+	}
+
+	level := i.level
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.Any("error", err))
+	}
+
+	i.logger.LogAttrs(context.Background(), level, call.Kind.String(), attrs...)
+}
+
+// Timing is a single entry of the TimingReport.
+type Timing struct {
+	Call     Call
+	Duration time.Duration
+	Err      error
+}
+
+// TimingReport is an interceptor that collects the durations of all calls made by the container, except for the cache hits.
+// Note that the durations are inclusive: the duration of a service instantiation includes
+// the instantiation of all its dependencies that had not been instantiated before.
+type TimingReport struct {
+	mu      sync.Mutex
+	timings []Timing
+}
+
+func NewTimingReport() *TimingReport {
+	return &TimingReport{}
+}
+
+func (r *TimingReport) Before(Call) {}
+
+func (r *TimingReport) After(call Call, d time.Duration, err error) {
+	if call.Kind == ServiceCacheHit {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timings = append(r.timings, Timing{Call: call, Duration: d, Err: err})
+}
+
+// Timings returns all collected timings, in order of the calls' completion.
+func (r *TimingReport) Timings() []Timing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.timings)
+}
+
+// Print prints the collected timings to the given writer, the slowest calls first.
+func (r *TimingReport) Print(w io.Writer) {
+	timings := r.Timings()
+	slices.SortStableFunc(timings, func(a, b Timing) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "DURATION\tCALL\tSCOPE\tERROR")
+	for _, t := range timings {
+		errStr := "-"
+		if t.Err != nil {
+			errStr = t.Err.Error()
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.Duration, t.Call, t.Call.Scope, errStr)
+	}
+	_ = tw.Flush()
+}
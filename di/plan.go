@@ -0,0 +1,311 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/michalkurzeja/godi/v2/internal/errorsx"
+	"github.com/michalkurzeja/godi/v2/internal/util"
+)
+
+// plan is a precompiled resolution of the arguments of a function in a scope.
+// Its steps reference the target definitions directly, so resolving the arguments
+// needs no lookups by type, label or name, nor binding checks.
+// Plans rely on the definitions and bindings not changing, which is guaranteed once the container is built.
+type plan struct {
+	scope *Scope
+	steps []planStep
+}
+
+// planStep resolves a single argument. It returns the same values and errors as the ArgResolver would.
+type planStep interface {
+	resolve(res resolution) (any, error)
+}
+
+// NewResolutionPlanningPass returns a compiler pass that precompiles the resolution plans
+// of the factories, methods and functions of all definitions (see Func.Execute).
+// Passes that modify the definitions must run before it.
+func NewResolutionPlanningPass() CompilerOpFunc {
+	return func(builder *ContainerBuilder) error {
+		for _, def := range builder.ServiceDefinitionsSeq() {
+			if def.factory != nil {
+				def.factory.fn.compile(def.EffectiveScope())
+			}
+			for _, method := range def.methodCalls {
+				method.fn.compile(def.EffectiveScope())
+			}
+		}
+		for _, def := range builder.FunctionDefinitionsSeq() {
+			if def.function != nil {
+				def.function.compile(def.EffectiveScope())
+			}
+		}
+		return nil
+	}
+}
+
+// compile precompiles the resolution plan of the function arguments in the given scope.
+// Invalid arguments are left to be reported by Execute.
+func (f *Func) compile(scope *Scope) {
+	args, err := f.args.ValidateAndCollect()
+	if err != nil {
+		f.plan = nil
+		return
+	}
+	steps := make([]planStep, len(args))
+	for i, arg := range args {
+		steps[i] = compileArg(scope, arg)
+	}
+	f.plan = &plan{scope: scope, steps: steps}
+}
+
+func (f *Func) executePlan(res resolution, receiver reflect.Value) ([]reflect.Value, error) {
+	resolvedArgs := make([]reflect.Value, len(f.plan.steps))
+	for i, step := range f.plan.steps {
+		if i == 0 && receiver.IsValid() {
+			resolvedArgs[i] = receiver
+			continue
+		}
+		val, err := step.resolve(res)
+		if err != nil {
+			return nil, errorsx.Wrapf(err, "failed to resolve argument %d", i)
+		}
+		resolvedArgs[i] = f.argValue(i, val)
+	}
+
+	if f.args.IsVariadic() {
+		return f.fn.CallSlice(resolvedArgs), nil
+	}
+	return f.fn.Call(resolvedArgs), nil
+}
+
+// compileArg mirrors ArgResolver.Resolve, resolving everything that doesn't depend on the instances upfront.
+func compileArg(scope *Scope, arg Arg) planStep {
+	switch a := arg.(type) {
+	case *literalArg:
+		return valueStep{v: a.v}
+	case *refArg:
+		// Looked up by ID, like the resolver does: the referenced definition may belong to another builder (see ContainerBuilder.Clone).
+		def, ok := scope.GetServiceDefinitionInChain(a.def.ID())
+		if !ok {
+			return errStep{err: fmt.Errorf("service %s not found", a.def.ID())}
+		}
+		return refStep{def: def}
+	case *typeArg:
+		if boundTo, ok := scope.GetBoundArgInChain(a.typ); ok {
+			return compileArg(scope, boundTo)
+		}
+		defs := scope.getServiceDefinitionsInChain(scope.GetServicesIDsByTypeInChain(a.typ))
+		switch {
+		case len(defs) == 0:
+			return errStep{err: fmt.Errorf("no services found for type %s", util.Signature(a.typ))}
+		case a.slice:
+			return servicesStep{scope: scope, defs: defs, elemType: a.typ, wrap: "failed to resolve type arg"}
+		case len(defs) > 1:
+			return errStep{err: fmt.Errorf("multiple services found for type %s", util.Signature(a.typ))}
+		}
+		return serviceStep{def: defs[0], wrap: "failed to resolve type arg"}
+	case *labelArg:
+		defs := scope.getServiceDefinitionsInChain(scope.GetServicesIDsByLabelInChain(a.label))
+		switch {
+		case len(defs) == 0:
+			return errStep{err: fmt.Errorf("no services found with label %s", a.label)}
+		case a.slice:
+			return servicesStep{scope: scope, defs: defs, elemType: a.typ, wrap: "failed to resolve type arg"}
+		case len(defs) > 1:
+			return errStep{err: fmt.Errorf("multiple services found for label %s", a.label)}
+		}
+		return labelStep{def: defs[0], arg: a}
+	case *nameArg:
+		id, ok := scope.GetServiceIDByNameInChain(a.name)
+		if !ok {
+			return errStep{err: fmt.Errorf("no service found with name %q", a.name)}
+		}
+		def, _ := scope.GetServiceDefinitionInChain(id)
+		return nameStep{def: def}
+	case *flexibleSliceArg:
+		return compileFlexibleSliceArg(scope, a)
+	case *compoundArg:
+		steps := make([]planStep, len(a.args))
+		for i, sub := range a.args {
+			steps[i] = compileArg(scope, sub)
+		}
+		return compoundStep{steps: steps, elemType: a.typ}
+	case *mapArg:
+		return compileMapArg(scope, a)
+	case *reversedArg:
+		return reversedStep{step: compileArg(scope, a.Arg)}
+	default:
+		return errStep{err: fmt.Errorf("unsupported arg type %T", arg)}
+	}
+}
+
+func compileFlexibleSliceArg(scope *Scope, a *flexibleSliceArg) planStep {
+	// First try to match by the slice type.
+	if boundTo, ok := scope.GetBoundArgInChain(a.Type()); ok {
+		return compileArg(scope, boundTo)
+	}
+	defs := scope.getServiceDefinitionsInChain(scope.GetServicesIDsByTypeInChain(a.Type()))
+	if len(defs) > 1 {
+		return errStep{err: fmt.Errorf("multiple services found for type %s", util.Signature(a.Type()))}
+	}
+	if len(defs) == 1 {
+		return serviceStep{def: defs[0], wrap: "failed to resolve flexible slice arg"} // Slice type matched!
+	}
+
+	// Now let's try to match by the element type.
+	elemType := a.Type().Elem()
+	if boundTo, ok := scope.GetBoundArgInChain(elemType); ok {
+		return compileArg(scope, boundTo)
+	}
+	defs = scope.getServiceDefinitionsInChain(scope.GetServicesIDsByTypeInChain(elemType))
+	if len(defs) > 0 || a.allowEmpty {
+		return servicesStep{scope: scope, defs: defs, elemType: elemType, wrap: "failed to resolve flexible slice arg element"} // Slice element type matched!
+	}
+
+	return errStep{err: fmt.Errorf("no services found for type %s", util.Signature(a.Type()))}
+}
+
+func compileMapArg(scope *Scope, a *mapArg) planStep {
+	entries, err := resolver.mapArgResolver.entries(scope, a)
+	if err != nil {
+		return errStep{err: err}
+	}
+	step := mapStep{typ: a.typ, keys: make([]reflect.Value, len(entries)), steps: make([]planStep, len(entries))}
+	for i, entry := range entries {
+		step.keys[i] = entry.key
+		step.steps[i] = compileArg(scope, entry.arg)
+	}
+	return step
+}
+
+type errStep struct{ err error }
+
+func (s errStep) resolve(resolution) (any, error) {
+	return nil, s.err
+}
+
+type valueStep struct{ v any }
+
+func (s valueStep) resolve(resolution) (any, error) {
+	return s.v, nil
+}
+
+type refStep struct{ def *ServiceDefinition }
+
+func (s refStep) resolve(res resolution) (any, error) {
+	v, err := s.def.scope.getServiceInstance(res, s.def)
+	if err != nil {
+		return nil, errorsx.Wrap(err, "failed to resolve ID arg")
+	}
+	if v == nil {
+		return nil, fmt.Errorf("service %s not found", s.def.ID())
+	}
+	return v, nil
+}
+
+type serviceStep struct {
+	def  *ServiceDefinition
+	wrap string
+}
+
+func (s serviceStep) resolve(res resolution) (any, error) {
+	v, err := s.def.scope.getServiceInstance(res, s.def)
+	if err != nil {
+		return nil, errorsx.Wrap(err, s.wrap)
+	}
+	return v, nil
+}
+
+type servicesStep struct {
+	scope    *Scope
+	defs     []*ServiceDefinition
+	elemType reflect.Type
+	wrap     string
+}
+
+func (s servicesStep) resolve(res resolution) (any, error) {
+	vals, err := s.scope.getServicesInstances(res, s.defs)
+	if err != nil {
+		return nil, errorsx.Wrap(err, s.wrap)
+	}
+	return convertSlice(vals, s.elemType)
+}
+
+type labelStep struct {
+	def *ServiceDefinition
+	arg *labelArg
+}
+
+func (s labelStep) resolve(res resolution) (any, error) {
+	v, err := s.def.scope.getServiceInstance(res, s.def)
+	if err != nil {
+		return nil, errorsx.Wrap(err, "failed to resolve type arg")
+	}
+	argType := reflect.TypeOf(v)
+	if argType != s.arg.Type() {
+		return nil, fmt.Errorf("service labeled as %s should be of type %s, got %s", s.arg.label, util.Signature(s.arg.Type()), util.Signature(argType))
+	}
+	return v, nil
+}
+
+type nameStep struct {
+	def *ServiceDefinition
+}
+
+func (s nameStep) resolve(res resolution) (any, error) {
+	v, err := s.def.scope.getServiceInstance(res, s.def)
+	if err != nil {
+		return nil, errorsx.Wrap(err, "failed to resolve name arg")
+	}
+	return v, nil
+}
+
+type compoundStep struct {
+	steps    []planStep
+	elemType reflect.Type
+}
+
+func (s compoundStep) resolve(res resolution) (any, error) {
+	vals := make([]any, len(s.steps))
+	for i, step := range s.steps {
+		v, err := step.resolve(res)
+		if err != nil {
+			return nil, errorsx.Wrapf(err, "failed to resolve compound sub-arg %d", i)
+		}
+		vals[i] = v
+	}
+	return convertSlice(vals, s.elemType)
+}
+
+type mapStep struct {
+	typ   reflect.Type
+	keys  []reflect.Value
+	steps []planStep
+}
+
+func (s mapStep) resolve(res resolution) (any, error) {
+	m := reflect.MakeMapWithSize(s.typ, len(s.steps))
+	for i, step := range s.steps {
+		v, err := step.resolve(res)
+		if err != nil {
+			return nil, errorsx.Wrapf(err, "failed to resolve map sub-arg %d", i)
+		}
+		rv := reflect.ValueOf(v)
+		if !rv.Type().AssignableTo(s.typ.Elem()) {
+			return nil, fmt.Errorf("type %s is not assignable to %s", util.Signature(rv.Type()), util.Signature(s.typ.Elem()))
+		}
+		m.SetMapIndex(s.keys[i], rv)
+	}
+	return m.Interface(), nil
+}
+
+type reversedStep struct{ step planStep }
+
+func (s reversedStep) resolve(res resolution) (any, error) {
+	v, err := s.step.resolve(res)
+	if err != nil {
+		return nil, err
+	}
+	return reverseSlice(v)
+}
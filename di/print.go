@@ -3,6 +3,7 @@ package di
 import (
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 
 	"github.com/michalkurzeja/godi/v2/internal/util"
@@ -21,6 +22,18 @@ func Print(s *Scope, w io.Writer) {
 		return arg
 	}
 
+	// writeOrder writes the effective order of the services that a slice argument resolves to.
+	writeOrder := func(w io.Writer, indent string, scope *Scope, arg Arg) {
+		if _, ok := arg.(*compoundArg); !ok && arg.Type().Kind() != reflect.Slice {
+			return
+		}
+		for i, id := range ResolveArgIDs(scope, arg) {
+			if def, ok := scope.GetServiceDefinitionInChain(id); ok {
+				write(w, fmt.Sprintf("%s%d. %s\n", indent, i+1, def))
+			}
+		}
+	}
+
 	bindings := s.GetBindings()
 	svcs := s.svcs.GetAll()
 	funs := s.funs.GetAll()
@@ -48,16 +61,29 @@ func Print(s *Scope, w io.Writer) {
 			write(w, fmt.Sprintf("%s\n", strings.Repeat("-", 80)))
 		}
 		write(w, fmt.Sprintf("Type:\t\t%s\n", def))
+		if def.Name() != "" {
+			write(w, fmt.Sprintf("Name:\t\t%s\n", def.Name()))
+		}
 		write(w, fmt.Sprintf("Factory:\t%s\n", def.FactoryName()))
 		write(w, fmt.Sprintf("Autowire:\t%t\n", def.IsAutowired()))
 		write(w, fmt.Sprintf("Shared:\t\t%t\n", def.IsShared()))
 		write(w, fmt.Sprintf("Lazy:\t\t%t\n", def.IsLazy()))
+		if def.Priority() != 0 {
+			write(w, fmt.Sprintf("Priority:\t%d\n", def.Priority()))
+		}
+		if !def.ErrorPolicy().IsZero() {
+			write(w, fmt.Sprintf("On error:\t%s\n", def.ErrorPolicy()))
+		}
+		if len(def.Tags()) > 0 {
+			write(w, fmt.Sprintf("Tags:\t\t%s\n", def.Tags()))
+		}
 
 		if len(def.Factory().Args().Slots()) > 0 {
 			write(w, "Arguments:\n")
 		}
 		for _, slot := range def.Factory().Args().Slots() {
 			write(w, fmt.Sprintf(" - %s\n", resolveBinding(slot.Arg())))
+			writeOrder(w, "\t", def.EffectiveScope(), slot.Arg())
 		}
 
 		if len(def.MethodCalls()) > 0 {
@@ -69,6 +95,7 @@ func Print(s *Scope, w io.Writer) {
 			}
 			for _, slot := range method.Args().Slots()[1:] {
 				write(w, fmt.Sprintf("\t- %s\n", resolveBinding(slot.Arg())))
+				writeOrder(w, "\t\t", def.EffectiveScope(), slot.Arg())
 			}
 		}
 	}
@@ -85,12 +112,16 @@ func Print(s *Scope, w io.Writer) {
 		write(w, fmt.Sprintf("Name:\t\t%s\n", def))
 		write(w, fmt.Sprintf("Autowire:\t%t\n", def.IsAutowired()))
 		write(w, fmt.Sprintf("Lazy:\t\t%t\n", def.IsLazy()))
+		if len(def.Tags()) > 0 {
+			write(w, fmt.Sprintf("Tags:\t\t%s\n", def.Tags()))
+		}
 
 		if len(def.Func().Args().Slots()) > 0 {
 			write(w, "Arguments:\n")
 		}
 		for _, slot := range def.Func().Args().Slots() {
 			write(w, fmt.Sprintf(" - %s\n", resolveBinding(slot.Arg())))
+			writeOrder(w, "\t", def.EffectiveScope(), slot.Arg())
 		}
 	}
 }
@@ -0,0 +1,120 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/michalkurzeja/godi/v2/internal/errorsx"
+	"github.com/michalkurzeja/godi/v2/internal/iterx"
+)
+
+// RefreshEvent describes the services whose instances were dropped by Container.Refresh.
+type RefreshEvent struct {
+	// Definitions of the dropped services, the dependents before their dependencies.
+	Definitions []ServiceDefinitionView
+}
+
+// IDs returns the IDs of the dropped services.
+func (e RefreshEvent) IDs() []ID {
+	return definitionIDs(e.Definitions)
+}
+
+// RefreshHandler is notified about refreshed services, e.g. to fetch new instances of the services it holds.
+type RefreshHandler func(RefreshEvent)
+
+// OnRefresh registers handlers that are called after each Refresh.
+// It must not be called concurrently with Refresh, e.g. register the handlers right after the container is built.
+func (c *Container) OnRefresh(handlers ...RefreshHandler) {
+	c.refreshHandlers = append(c.refreshHandlers, handlers...)
+}
+
+// Refresh drops the cached instances of the given services, and of all services that depend on them
+// (directly or transitively), so that they are instantiated again on the next resolution.
+// It also drops the cached errors of their failed instantiations (see ErrorPolicy.CacheErrors).
+// The dropped instances that implement io.Closer are closed, the dependents before their dependencies.
+// Afterwards, the refresh handlers are notified (see OnRefresh), even if closing some of the instances failed.
+// Refresh is safe to call concurrently with the resolution of services. The services that are being
+// instantiated during the refresh are handed to the resolutions already waiting for them, but they are
+// not cached, so the resolutions started after the refresh get new instances.
+func (c *Container) Refresh(ids ...ID) error {
+	defs := make([]*ServiceDefinition, 0, len(ids))
+	for _, id := range ids {
+		def, ok := c.serviceDefinition(id)
+		if !ok {
+			return fmt.Errorf("cannot refresh service %s: service not found", id)
+		}
+		defs = append(defs, def)
+	}
+
+	var joinedErrs error
+	var dropped []*ServiceDefinition
+	for _, def := range c.dependentsOf(defs) {
+		def.scope.mu.Lock()
+		svc, ok := def.scope.instances[def.id]
+		delete(def.scope.instances, def.id)
+		delete(def.scope.failures, def.id)
+		if f, ok := def.scope.flights[def.id]; ok {
+			f.refreshed = true
+			delete(def.scope.flights, def.id)
+		}
+		def.scope.mu.Unlock()
+		if !ok {
+			continue
+		}
+		dropped = append(dropped, def)
+
+		if closer, ok := svc.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				joinedErrs = errors.Join(joinedErrs, errorsx.Wrapf(err, "failed to close service %s", def))
+			}
+		}
+	}
+
+	event := RefreshEvent{Definitions: viewsOf(dropped)}
+	for _, handler := range c.refreshHandlers {
+		handler(event)
+	}
+
+	return joinedErrs
+}
+
+// dependentsOf returns the given services and all services that depend on them, the dependents first.
+func (c *Container) dependentsOf(defs []*ServiceDefinition) []*ServiceDefinition {
+	dependents := make(map[ID][]*ServiceDefinition)
+	for scope := range iterx.Values(c.scopes.Iterator()) {
+		for def := range scope.svcs.Seq() {
+			for _, id := range ResolveServiceDependencyIDs(def) {
+				dependents[id] = append(dependents[id], def)
+			}
+		}
+	}
+
+	var result []*ServiceDefinition
+	visited := make(map[ID]bool)
+	var visit func(def *ServiceDefinition)
+	visit = func(def *ServiceDefinition) {
+		if visited[def.id] {
+			return
+		}
+		visited[def.id] = true
+		for _, dependent := range dependents[def.id] {
+			visit(dependent)
+		}
+		result = append(result, def)
+	}
+	for _, def := range defs {
+		visit(def)
+	}
+
+	return result
+}
+
+func (c *Container) serviceDefinition(id ID) (*ServiceDefinition, bool) {
+	for scope := range iterx.Values(c.scopes.Iterator()) {
+		if def, ok := scope.GetServiceDefinition(id); ok {
+			return def, true
+		}
+	}
+	return nil, false
+}
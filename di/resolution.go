@@ -0,0 +1,22 @@
+package di
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+var resolutionIDs atomic.Uint64
+
+// resolution identifies a single top-level resolution (e.g. Container.GetService) together with
+// the nested resolutions of the dependencies it triggers. The nested resolutions are made synchronously,
+// in the goroutine of the top-level one, so a service that is resolved again while it is being instantiated
+// by the same resolution is a re-entrant resolution, not a concurrent one (see getSharedServiceInstance).
+type resolution struct {
+	id   uint64
+	ctx  context.Context // The context the resolution was started with, see Call.Context.
+	call uint64          // ID of the innermost unfinished call, if the calls are intercepted.
+}
+
+func newResolution(ctx context.Context) resolution {
+	return resolution{id: resolutionIDs.Add(1), ctx: ctx}
+}
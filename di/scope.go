@@ -1,11 +1,14 @@
 package di
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"iter"
 	"reflect"
 	"slices"
+	"sync"
 
 	"github.com/elliotchance/orderedmap/v2"
 	"github.com/samber/lo"
@@ -23,6 +26,8 @@ func NewScope(name string, container *Container, parent *Scope) *Scope {
 		funs:      NewDefinitionRegistry[*FunctionDefinition](),
 		bindings:  orderedmap.NewOrderedMap[reflect.Type, *InterfaceBinding](),
 		instances: make(map[ID]any),
+		failures:  make(map[ID]error),
+		flights:   make(map[ID]*flight),
 	}
 	container.scopes.Set(name, s)
 	return s
@@ -34,10 +39,17 @@ type Scope struct {
 	container *Container
 	parent    *Scope
 
-	svcs      *DefinitionRegistry[*ServiceDefinition]
-	funs      *DefinitionRegistry[*FunctionDefinition]
-	bindings  *orderedmap.OrderedMap[reflect.Type, *InterfaceBinding]
+	svcs     *DefinitionRegistry[*ServiceDefinition]
+	funs     *DefinitionRegistry[*FunctionDefinition]
+	bindings *orderedmap.OrderedMap[reflect.Type, *InterfaceBinding]
+
+	// mu guards the instances, failures and flights, so that services can be resolved concurrently.
+	mu        sync.Mutex
 	instances map[ID]any
+	failures  map[ID]error // Cached errors of failed instantiations (see ErrorPolicy.CacheErrors).
+	flights   map[ID]*flight
+
+	frozen bool
 }
 
 func (s *Scope) String() string {
@@ -49,6 +61,7 @@ func (s *Scope) Name() string {
 }
 
 func (s *Scope) NewChild(name string) *Scope {
+	s.mustNotBeFrozen()
 	return NewScope(name, s.container, s)
 }
 
@@ -77,7 +90,12 @@ func (s *Scope) Functions() *DefinitionRegistry[*FunctionDefinition] {
 	return s.funs
 }
 
+// Bindings returns the interface bindings of the scope, keyed by the interface type.
+// Once the scope is frozen, it returns a copy, so the bindings of a built container cannot be modified.
 func (s *Scope) Bindings() *orderedmap.OrderedMap[reflect.Type, *InterfaceBinding] {
+	if s.frozen {
+		return s.bindings.Copy()
+	}
 	return s.bindings
 }
 
@@ -95,16 +113,24 @@ func (s *Scope) HasServiceInChain(id ID) bool {
 }
 
 func (s *Scope) GetService(id ID) (any, error) {
+	return s.getService(newResolution(context.Background()), id)
+}
+
+func (s *Scope) getService(res resolution, id ID) (any, error) {
 	def, ok := s.svcs.Get(id)
 	if !ok {
 		return nil, nil
 	}
-	return s.getServiceInstance(def)
+	return s.getServiceInstance(res, def)
 }
 
 func (s *Scope) GetServiceInChain(id ID) (any, error) {
+	return s.getServiceInChain(newResolution(context.Background()), id)
+}
+
+func (s *Scope) getServiceInChain(res resolution, id ID) (any, error) {
 	for scope := range s.Chain() {
-		svc, err := scope.GetService(id)
+		svc, err := scope.getService(res, id)
 		if svc != nil || err != nil {
 			return svc, err
 		}
@@ -112,46 +138,107 @@ func (s *Scope) GetServiceInChain(id ID) (any, error) {
 	return nil, nil
 }
 
+// GetServices returns the services with the given IDs, in the order of the IDs.
+// The services retrieved by type or label are ordered by priority, as their IDs are.
 func (s *Scope) GetServices(ids ...ID) ([]any, error) {
-	return s.getServicesInstances(s.svcs.GetByIDs(ids))
+	return s.getServicesInstances(newResolution(context.Background()), s.svcs.GetByIDs(ids))
 }
 
 func (s *Scope) GetServicesInChain(ids ...ID) ([]any, error) {
-	var defs []*ServiceDefinition
-	for scope := range s.Chain() {
-		defs = append(defs, scope.svcs.GetByIDs(ids)...)
-	}
-	return s.getServicesInstances(defs)
+	return s.getServicesInChain(newResolution(context.Background()), ids...)
+}
+
+func (s *Scope) getServicesInChain(res resolution, ids ...ID) ([]any, error) {
+	return s.getServicesInstances(res, s.getServiceDefinitionsInChain(ids))
 }
 
 func (s *Scope) GetServicesIDsByType(typ reflect.Type) []ID {
-	return s.svcs.GetIDsByType(typ)
+	return definitionIDs(s.GetServiceDefinitionsByType(typ))
 }
 
-func (s *Scope) GetServicesIDsByTypeInChain(typ reflect.Type) (ids []ID) {
-	for scope := range s.Chain() {
-		ids = append(ids, scope.GetServicesIDsByType(typ)...)
-	}
-	return ids
+func (s *Scope) GetServicesIDsByTypeInChain(typ reflect.Type) []ID {
+	return definitionIDs(s.GetServiceDefinitionsByTypeInChain(typ))
 }
 
 func (s *Scope) GetServicesByType(typ reflect.Type) ([]any, error) {
 	return s.GetServices(s.GetServicesIDsByType(typ)...)
 }
 
+// GetServicesByTypeContext is like GetServicesByType, but the calls made by the resolution carry the given context,
+// see Call.Context.
+func (s *Scope) GetServicesByTypeContext(ctx context.Context, typ reflect.Type) ([]any, error) {
+	return s.getServicesInstances(newResolution(ctx), s.svcs.GetByIDs(s.GetServicesIDsByType(typ)))
+}
+
 func (s *Scope) GetServicesByTypeInChain(typ reflect.Type) ([]any, error) {
-	return s.GetServicesInChain(s.GetServicesIDsByTypeInChain(typ)...)
+	return s.getServicesByTypeInChain(newResolution(context.Background()), typ)
 }
 
-func (s *Scope) GetServicesIDsByLabel(label Label) []ID {
-	return s.svcs.GetIDsByLabel(label)
+func (s *Scope) getServicesByTypeInChain(res resolution, typ reflect.Type) ([]any, error) {
+	return s.getServicesInChain(res, s.GetServicesIDsByTypeInChain(typ)...)
+}
+
+func (s *Scope) GetServiceIDByName(name string) (ID, bool) {
+	def, ok := s.svcs.GetByName(name)
+	if !ok {
+		return "", false
+	}
+	return def.ID(), true
 }
 
-func (s *Scope) GetServicesIDsByLabelInChain(label Label) (ids []ID) {
+func (s *Scope) GetServiceIDByNameInChain(name string) (ID, bool) {
 	for scope := range s.Chain() {
-		ids = append(ids, scope.GetServicesIDsByLabel(label)...)
+		if id, ok := scope.GetServiceIDByName(name); ok {
+			return id, true
+		}
 	}
-	return ids
+	return "", false
+}
+
+// ServiceNameNotFoundError is returned by the lookups of services by a name that no service has.
+// A named service whose factory returns nil is found, the lookup returns nil and no error.
+type ServiceNameNotFoundError struct {
+	Name string
+}
+
+func (e *ServiceNameNotFoundError) Error() string {
+	return fmt.Sprintf("service named %q not found", e.Name)
+}
+
+// GetServiceByName returns the service with the given name, or a *ServiceNameNotFoundError.
+func (s *Scope) GetServiceByName(name string) (any, error) {
+	return s.getServiceByName(newResolution(context.Background()), name)
+}
+
+func (s *Scope) getServiceByName(res resolution, name string) (any, error) {
+	def, ok := s.svcs.GetByName(name)
+	if !ok {
+		return nil, &ServiceNameNotFoundError{Name: name}
+	}
+	return s.getServiceInstance(res, def)
+}
+
+// GetServiceByNameInChain returns the service with the given name from the closest scope of the chain that has it,
+// or a *ServiceNameNotFoundError.
+func (s *Scope) GetServiceByNameInChain(name string) (any, error) {
+	return s.getServiceByNameInChain(newResolution(context.Background()), name)
+}
+
+func (s *Scope) getServiceByNameInChain(res resolution, name string) (any, error) {
+	for scope := range s.Chain() {
+		if def, ok := scope.svcs.GetByName(name); ok {
+			return scope.getServiceInstance(res, def)
+		}
+	}
+	return nil, &ServiceNameNotFoundError{Name: name}
+}
+
+func (s *Scope) GetServicesIDsByLabel(label Label) []ID {
+	return definitionIDs(s.GetServiceDefinitionsByLabel(label))
+}
+
+func (s *Scope) GetServicesIDsByLabelInChain(label Label) []ID {
+	return definitionIDs(s.GetServiceDefinitionsByLabelInChain(label))
 }
 
 func (s *Scope) GetServicesByLabel(label Label) ([]any, error) {
@@ -159,7 +246,25 @@ func (s *Scope) GetServicesByLabel(label Label) ([]any, error) {
 }
 
 func (s *Scope) GetServicesByLabelInChain(label Label) ([]any, error) {
-	return s.GetServicesInChain(s.GetServicesIDsByLabelInChain(label)...)
+	return s.getServicesByLabelInChain(newResolution(context.Background()), label)
+}
+
+func (s *Scope) getServicesByLabelInChain(res resolution, label Label) ([]any, error) {
+	return s.getServicesInChain(res, s.GetServicesIDsByLabelInChain(label)...)
+}
+
+// GetTaggedServices returns the services tagged with the given tag, each with the attributes of the tag.
+// A service tagged multiple times with the same tag is returned once per tag.
+func (s *Scope) GetTaggedServices(tag Label) ([]TaggedService, error) {
+	return s.getTaggedServicesInstances(newResolution(context.Background()), s.svcs.GetByLabel(tag), tag)
+}
+
+func (s *Scope) GetTaggedServicesInChain(tag Label) ([]TaggedService, error) {
+	var defs []*ServiceDefinition
+	for scope := range s.Chain() {
+		defs = append(defs, scope.svcs.GetByLabel(tag)...)
+	}
+	return s.getTaggedServicesInstances(newResolution(context.Background()), defs, tag)
 }
 
 func (s *Scope) HasFunction(id ID) bool {
@@ -180,14 +285,14 @@ func (s *Scope) ExecuteFunction(id ID) ([]any, error) {
 	if !ok {
 		return nil, fmt.Errorf("function %s not found", id)
 	}
-	return s.executeFunction(def)
+	return s.executeFunction(newResolution(context.Background()), def)
 }
 
 func (s *Scope) ExecuteFunctionInChain(id ID) ([]any, error) {
 	for scope := range s.Chain() {
 		def, ok := s.funs.Get(id)
 		if ok {
-			return scope.executeFunction(def)
+			return scope.executeFunction(newResolution(context.Background()), def)
 		}
 	}
 	return nil, fmt.Errorf("function %s not found", id)
@@ -198,7 +303,7 @@ func (s *Scope) ExecuteFunctions(ids ...ID) (results [][]any, joinedErrs error)
 	if len(defs) == 0 {
 		return nil, errors.New("found no functions for given IDs")
 	}
-	return s.executeFunctions(defs)
+	return s.executeFunctions(newResolution(context.Background()), defs)
 }
 
 func (s *Scope) ExecuteFunctionsInChain(ids ...ID) (results [][]any, joinedErrs error) {
@@ -209,7 +314,7 @@ func (s *Scope) ExecuteFunctionsInChain(ids ...ID) (results [][]any, joinedErrs
 	if len(defs) == 0 {
 		return nil, errors.New("found no functions for given IDs")
 	}
-	return s.executeFunctions(defs)
+	return s.executeFunctions(newResolution(context.Background()), defs)
 }
 
 func (s *Scope) GetFunctionsIDsByType(typ reflect.Type) []ID {
@@ -268,63 +373,100 @@ func (s *Scope) GetBoundArgInChain(typ reflect.Type) (Arg, bool) {
 	return nil, false
 }
 
-func (s *Scope) getServiceInstance(def *ServiceDefinition) (any, error) {
+func (s *Scope) getServiceInstance(res resolution, def *ServiceDefinition) (any, error) {
+	s.mu.Lock()
 	svc, ok := s.instances[def.ID()]
+	s.mu.Unlock()
 	if ok {
+		_ = s.container.intercept(res, Call{Kind: ServiceCacheHit, Definition: def, Scope: s}, func(resolution) error { return nil })
 		return svc, nil
 	}
 
-	svc, err := s.instantiate(def)
-	if err != nil {
-		return nil, errorsx.Wrapf(err, "failed to instantiate service %s", def)
+	if def.shared {
+		return s.getSharedServiceInstance(res, def)
 	}
-
-	return svc, nil
+	return s.instantiateWithRetries(res, def, nil)
 }
 
-func (s *Scope) getServicesInstances(defs []*ServiceDefinition) (svcs []any, joinedErrs error) {
+func (s *Scope) getServicesInstances(res resolution, defs []*ServiceDefinition) (svcs []any, joinedErrs error) {
 	svcs = make([]any, len(defs))
 	for i, def := range defs {
-		svc, err := s.getServiceInstance(def)
+		svc, err := def.scope.getServiceInstance(res, def) // Instances belong to the scope of their definitions.
 		svcs[i] = svc
 		joinedErrs = errors.Join(joinedErrs, err)
 	}
 	return svcs, joinedErrs
 }
 
-func (s *Scope) instantiate(def *ServiceDefinition) (any, error) {
-	svc, err := def.factory.Execute(def.EffectiveScope())
-	if err != nil {
-		return nil, errorsx.Wrapf(err, "failed to execute factory for service %s", def)
-	}
-
-	if def.shared {
-		s.instances[def.id] = svc
+func (s *Scope) getTaggedServicesInstances(res resolution, defs []*ServiceDefinition, tag Label) (svcs []TaggedService, joinedErrs error) {
+	for _, def := range defs {
+		tags := def.TagsByName(tag)
+		if len(tags) == 0 {
+			continue // Labelled, but not tagged.
+		}
+		svc, err := def.scope.getServiceInstance(res, def)
+		if err != nil {
+			joinedErrs = errors.Join(joinedErrs, err)
+			continue
+		}
+		for _, t := range tags {
+			svcs = append(svcs, TaggedService{Svc: svc, Attrs: t.Attrs})
+		}
 	}
+	return svcs, joinedErrs
+}
 
-	for _, method := range def.MethodCalls() {
-		err = method.Execute(def.EffectiveScope())
+// instantiate creates the service and executes its method calls. If the service is shared, f is its flight,
+// which holds the created service while its method calls are executed, so that they can depend on it.
+func (s *Scope) instantiate(res resolution, def *ServiceDefinition, f *flight) (any, error) {
+	var svc any
+	err := s.container.intercept(res, Call{Kind: ServiceInstantiation, Definition: def, Scope: s}, func(res resolution) (err error) {
+		svc, err = def.factory.execute(res, def.EffectiveScope())
 		if err != nil {
-			return nil, errorsx.Wrapf(err, "failed to execute method %s of service %s", method, def)
+			return errorsx.Wrapf(err, "failed to execute factory for service %s", def)
 		}
+		if f != nil {
+			f.created, f.svc = true, svc
+		}
+
+		for _, method := range def.MethodCalls() {
+			err = s.container.intercept(res, Call{Kind: MethodCall, Definition: def, Method: method, Scope: s}, func(res resolution) error {
+				return method.executeOn(res, def.EffectiveScope(), def, svc)
+			})
+			if err != nil {
+				return errorsx.Wrapf(err, "failed to execute method %s of service %s", method, def)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return svc, nil
 }
 
-func (s *Scope) executeFunction(def *FunctionDefinition) ([]any, error) {
-	res, err := def.function.Execute(def.EffectiveScope())
+func (s *Scope) executeFunction(res resolution, def *FunctionDefinition) ([]any, error) {
+	var out []reflect.Value
+	err := s.container.intercept(res, Call{Kind: FunctionCall, Definition: def, Scope: s}, func(res resolution) (err error) {
+		out, err = def.function.execute(res, def.EffectiveScope(), reflect.Value{})
+		if err != nil {
+			return errorsx.Wrapf(err, "failed to execute function %s", def)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, errorsx.Wrapf(err, "failed to execute function %s", def)
+		return nil, err
 	}
-	return lo.Map(res, func(v reflect.Value, _ int) any { return v.Interface() }), nil
+	return lo.Map(out, func(v reflect.Value, _ int) any { return v.Interface() }), nil
 }
 
-func (s *Scope) executeFunctions(defs []*FunctionDefinition) (results [][]any, joinedErrs error) {
+func (s *Scope) executeFunctions(res resolution, defs []*FunctionDefinition) (results [][]any, joinedErrs error) {
 	results = make([][]any, len(defs))
 	for i, def := range defs {
-		res, err := s.executeFunction(def)
-		results[i] = res
+		out, err := s.executeFunction(res, def)
+		results[i] = out
 		joinedErrs = errors.Join(joinedErrs, err)
 	}
 	return results, joinedErrs
@@ -354,32 +496,47 @@ func (s *Scope) GetServiceDefinitionsInChain() []*ServiceDefinition {
 	return iterx.Collect(s.ServiceDefinitionsInChainSeq())
 }
 
+// GetServiceDefinitionsByType returns the definitions of the given type, ordered by priority.
 func (s *Scope) GetServiceDefinitionsByType(typ reflect.Type) []*ServiceDefinition {
-	return s.svcs.GetByType(typ)
+	return sortByPriority(slices.Clone(s.svcs.GetByType(typ)))
 }
 
+// GetServiceDefinitionsByTypeInChain returns the definitions of the given type from the whole scope chain, ordered by priority.
 func (s *Scope) GetServiceDefinitionsByTypeInChain(typ reflect.Type) (defs []*ServiceDefinition) {
 	for scope := range s.Chain() {
-		defs = append(defs, scope.GetServiceDefinitionsByType(typ)...)
+		defs = append(defs, scope.svcs.GetByType(typ)...)
 	}
-	return defs
+	return sortByPriority(defs)
 }
 
+// GetServiceDefinitionsByLabel returns the definitions with the given label, ordered by priority.
 func (s *Scope) GetServiceDefinitionsByLabel(label Label) []*ServiceDefinition {
-	return s.svcs.GetByLabel(label)
+	return sortByPriority(slices.Clone(s.svcs.GetByLabel(label)))
 }
 
+// GetServiceDefinitionsByLabelInChain returns the definitions with the given label from the whole scope chain, ordered by priority.
 func (s *Scope) GetServiceDefinitionsByLabelInChain(label Label) (defs []*ServiceDefinition) {
 	for scope := range s.Chain() {
-		defs = append(defs, scope.GetServiceDefinitionsByLabel(label)...)
+		defs = append(defs, scope.svcs.GetByLabel(label)...)
 	}
-	return defs
+	return sortByPriority(defs)
 }
 
 func (s *Scope) GetServiceDefinition(id ID) (*ServiceDefinition, bool) {
 	return s.svcs.Get(id)
 }
 
+// getServiceDefinitionsInChain returns the definitions with the given IDs from the whole scope chain, in the order of the IDs.
+func (s *Scope) getServiceDefinitionsInChain(ids []ID) []*ServiceDefinition {
+	defs := make([]*ServiceDefinition, 0, len(ids))
+	for _, id := range ids {
+		if def, ok := s.GetServiceDefinitionInChain(id); ok {
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
 func (s *Scope) GetServiceDefinitionInChain(id ID) (*ServiceDefinition, bool) {
 	for scope := range s.Chain() {
 		if def, ok := scope.GetServiceDefinition(id); ok {
@@ -390,16 +547,28 @@ func (s *Scope) GetServiceDefinitionInChain(id ID) (*ServiceDefinition, bool) {
 }
 
 func (s *Scope) AddServiceDefinitions(definitions ...*ServiceDefinition) *Scope {
+	s.mustNotBeFrozen()
+	if ids := s.container.ids; ids != nil {
+		for _, def := range definitions {
+			var factoryName string
+			if def.factory != nil {
+				factoryName = def.FactoryName()
+			}
+			def.id = ids.next(s, def.id, "service", factoryName)
+		}
+	}
 	s.svcs.Add(definitions...)
 	return s
 }
 
 func (s *Scope) RemoveServiceDefinitions(ids ...ID) *Scope {
+	s.mustNotBeFrozen()
 	s.svcs.Remove(ids...)
 	return s
 }
 
 func (s *Scope) ClearServiceDefinitions() *Scope {
+	s.mustNotBeFrozen()
 	s.svcs.Clear()
 	return s
 }
@@ -464,11 +633,22 @@ func (s *Scope) GetFunctionDefinitionInChain(id ID) (*FunctionDefinition, bool)
 }
 
 func (s *Scope) AddFunctionDefinitions(functions ...*FunctionDefinition) *Scope {
+	s.mustNotBeFrozen()
+	if ids := s.container.ids; ids != nil {
+		for _, def := range functions {
+			var funcName string
+			if def.function != nil {
+				funcName = def.function.Name()
+			}
+			def.id = ids.next(s, def.id, "function", funcName)
+		}
+	}
 	s.funs.Add(functions...)
 	return s
 }
 
 func (s *Scope) RemoveFunctionDefinitions(ids ...ID) *Scope {
+	s.mustNotBeFrozen()
 	s.funs.Remove(ids...)
 	return s
 }
@@ -486,12 +666,23 @@ func (s *Scope) GetBinding(typ reflect.Type) (*InterfaceBinding, bool) {
 	return binding, ok
 }
 
+func (s *Scope) GetBindingInChain(typ reflect.Type) (*InterfaceBinding, bool) {
+	for scope := range s.Chain() {
+		if binding, ok := scope.GetBinding(typ); ok {
+			return binding, true
+		}
+	}
+	return nil, false
+}
+
 func (s *Scope) SetBindings(bindings ...*InterfaceBinding) *Scope {
+	s.mustNotBeFrozen()
 	s.bindings = orderedmap.NewOrderedMap[reflect.Type, *InterfaceBinding]()
 	return s.AddBindings(bindings...)
 }
 
 func (s *Scope) AddBindings(bindings ...*InterfaceBinding) *Scope {
+	s.mustNotBeFrozen()
 	for _, binding := range bindings {
 		s.bindings.Set(binding.ifaceTyp, binding)
 	}
@@ -499,6 +690,7 @@ func (s *Scope) AddBindings(bindings ...*InterfaceBinding) *Scope {
 }
 
 func (s *Scope) RemoveBindings(types ...reflect.Type) *Scope {
+	s.mustNotBeFrozen()
 	for _, typ := range types {
 		s.bindings.Delete(typ)
 	}
@@ -511,10 +703,18 @@ type Definition interface {
 	Labels() []Label
 }
 
+// namedDefinition is implemented by definitions that can be given a unique name.
+type namedDefinition interface {
+	Name() string
+}
+
 type DefinitionRegistry[Def Definition] struct {
 	byID    *orderedmap.OrderedMap[ID, Def]
 	byType  *orderedmap.OrderedMap[reflect.Type, []Def]
 	byLabel *orderedmap.OrderedMap[Label, []Def]
+	byName  map[string]Def
+
+	frozen bool
 }
 
 func NewDefinitionRegistry[Def Definition]() *DefinitionRegistry[Def] {
@@ -524,6 +724,7 @@ func NewDefinitionRegistry[Def Definition]() *DefinitionRegistry[Def] {
 }
 
 func (r *DefinitionRegistry[Def]) Add(defs ...Def) {
+	r.mustNotBeFrozen()
 	for _, d := range defs {
 		r.byID.Set(d.ID(), d)
 		byType := r.byType.GetOrDefault(d.Type(), nil)
@@ -532,10 +733,14 @@ func (r *DefinitionRegistry[Def]) Add(defs ...Def) {
 			byLabel := r.byLabel.GetOrDefault(label, nil)
 			r.byLabel.Set(label, append(byLabel, d))
 		}
+		if name := definitionName(d); name != "" {
+			r.byName[name] = d
+		}
 	}
 }
 
 func (r *DefinitionRegistry[Def]) Remove(ids ...ID) {
+	r.mustNotBeFrozen()
 	for _, id := range ids {
 		def, ok := r.byID.Get(id)
 		if !ok {
@@ -551,13 +756,18 @@ func (r *DefinitionRegistry[Def]) Remove(ids ...ID) {
 			byLabel := r.byLabel.GetOrDefault(label, nil)
 			r.byLabel.Set(label, slices.DeleteFunc(byLabel, defEq))
 		}
+		if named, ok := r.byName[definitionName(def)]; ok && defEq(named) {
+			delete(r.byName, definitionName(def))
+		}
 	}
 }
 
 func (r *DefinitionRegistry[Def]) Clear() {
+	r.mustNotBeFrozen()
 	r.byID = orderedmap.NewOrderedMap[ID, Def]()
 	r.byType = orderedmap.NewOrderedMap[reflect.Type, []Def]()
 	r.byLabel = orderedmap.NewOrderedMap[Label, []Def]()
+	r.byName = make(map[string]Def)
 }
 
 func (r *DefinitionRegistry[Def]) Contains(id ID) bool {
@@ -597,6 +807,11 @@ func (r *DefinitionRegistry[Def]) GetByLabel(label Label) []Def {
 	return r.byLabel.GetOrDefault(label, nil)
 }
 
+func (r *DefinitionRegistry[Def]) GetByName(name string) (Def, bool) {
+	def, ok := r.byName[name]
+	return def, ok
+}
+
 func (r *DefinitionRegistry[Def]) Seq() iter.Seq[Def] {
 	return iterx.Values(r.byID.Iterator())
 }
@@ -612,3 +827,23 @@ func (r *DefinitionRegistry[Def]) Len() int {
 func (r *DefinitionRegistry[Def]) getIDs(defs []Def) []ID {
 	return lo.Map(defs, func(d Def, _ int) ID { return d.ID() })
 }
+
+func definitionName(def Definition) string {
+	if named, ok := def.(namedDefinition); ok {
+		return named.Name()
+	}
+	return ""
+}
+
+// sortByPriority sorts the definitions by priority, the highest first.
+// Definitions with equal priority keep their order, i.e. the scope chain order and then the order of registration.
+func sortByPriority(defs []*ServiceDefinition) []*ServiceDefinition {
+	slices.SortStableFunc(defs, func(a, b *ServiceDefinition) int {
+		return cmp.Compare(b.priority, a.priority)
+	})
+	return defs
+}
+
+func definitionIDs[Def Definition](defs []Def) []ID {
+	return lo.Map(defs, func(d Def, _ int) ID { return d.ID() })
+}
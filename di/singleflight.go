@@ -0,0 +1,124 @@
+package di
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/michalkurzeja/godi/v2/internal/errorsx"
+)
+
+// ErrorPolicy determines how the failed instantiations of a service are handled.
+// The zero value neither retries nor caches the failures: the next resolution instantiates the service again.
+type ErrorPolicy struct {
+	// Retries is the number of times a failed instantiation is retried before its error is returned.
+	Retries int
+	// Backoff is the delay before the first retry. It doubles with each subsequent retry.
+	Backoff time.Duration
+	// CacheErrors makes all subsequent resolutions of a shared service fail with the error of its failed
+	// instantiation, without instantiating it again, until the service is refreshed (see Container.Refresh).
+	CacheErrors bool
+}
+
+func (p ErrorPolicy) IsZero() bool {
+	return p == ErrorPolicy{}
+}
+
+func (p ErrorPolicy) String() string {
+	s := fmt.Sprintf("retries: %d, backoff: %s", p.Retries, p.Backoff)
+	if p.CacheErrors {
+		s += ", cache errors"
+	}
+	return s
+}
+
+// flight is an in-progress instantiation of a shared service. Concurrent resolutions of the service
+// wait for it to complete, instead of executing the factory again.
+type flight struct {
+	done  chan struct{}
+	owner uint64 // ID of the resolution that instantiates the service.
+
+	// The service is set once created, before its method calls are executed. The method calls may resolve
+	// the service again (e.g. a setter injection of a dependency that depends on the service), within the
+	// owner resolution, and get the service before it's complete.
+	svc     any
+	created bool
+	err     error
+
+	// Set if the service is refreshed during the instantiation (see Container.Refresh), guarded by Scope.mu.
+	// The instance may be created from the dropped instances of its dependencies, so it's not cached,
+	// and the flight is detached, so that the resolutions started after the refresh instantiate the service again.
+	refreshed bool
+}
+
+// getSharedServiceInstance instantiates a shared service that is not cached yet, or waits for
+// its in-progress instantiation to complete.
+func (s *Scope) getSharedServiceInstance(res resolution, def *ServiceDefinition) (any, error) {
+	s.mu.Lock()
+	if svc, ok := s.instances[def.id]; ok {
+		s.mu.Unlock() // Instantiated in the meantime.
+		return svc, nil
+	}
+	if err, ok := s.failures[def.id]; ok {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if f, ok := s.flights[def.id]; ok {
+		s.mu.Unlock()
+		if f.owner == res.id {
+			// Re-entrant resolution: waiting for the flight would deadlock, as it waits for this resolution.
+			if !f.created {
+				return nil, fmt.Errorf("circular dependency: service %s depends on itself", def)
+			}
+			return f.svc, nil
+		}
+		<-f.done
+		return f.svc, f.err
+	}
+	f := &flight{done: make(chan struct{}), owner: res.id}
+	s.flights[def.id] = f
+	s.mu.Unlock()
+
+	completed := false
+	defer func() {
+		if !completed {
+			f.err = fmt.Errorf("instantiation of service %s panicked", def) // Don't block the waiting resolutions.
+		}
+		s.mu.Lock()
+		switch {
+		case f.refreshed:
+			// Only the resolutions that waited for the flight before the refresh get the instance.
+		case f.err == nil:
+			s.instances[def.id] = f.svc // Cached only once its method calls succeeded, so it's never seen half-built.
+		case def.errorPolicy.CacheErrors:
+			s.failures[def.id] = f.err
+		}
+		if !f.refreshed {
+			delete(s.flights, def.id)
+		}
+		s.mu.Unlock()
+		close(f.done)
+	}()
+
+	f.svc, f.err = s.instantiateWithRetries(res, def, f)
+	completed = true
+	return f.svc, f.err
+}
+
+// instantiateWithRetries instantiates the service, retrying the failed attempts according to its ErrorPolicy.
+func (s *Scope) instantiateWithRetries(res resolution, def *ServiceDefinition, f *flight) (any, error) {
+	backoff := def.errorPolicy.Backoff
+	for attempt := 1; ; attempt++ {
+		svc, err := s.instantiate(res, def, f)
+		if err == nil {
+			return svc, nil
+		}
+		if attempt > def.errorPolicy.Retries {
+			if attempt > 1 {
+				err = errorsx.Wrapf(err, "gave up after %d attempts", attempt)
+			}
+			return nil, errorsx.Wrapf(err, "failed to instantiate service %s", def)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,125 @@
+package di
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefinitionStats holds the runtime counters of a single definition.
+type DefinitionStats struct {
+	Definition Definition
+	// Instantiations is the number of times the service was created.
+	// For a shared service it's at most 1 (unless the instantiation failed), for a not shared one it grows with every retrieval.
+	Instantiations uint64
+	// CacheHits is the number of times an already created shared service was retrieved.
+	CacheHits uint64
+	// Executions is the number of times the function was executed.
+	Executions uint64
+	// Errors is the number of failed instantiations or executions.
+	Errors uint64
+	// Duration is the cumulative duration of all instantiations or executions.
+	// It's inclusive: it contains the instantiation of the dependencies that had not been instantiated before.
+	Duration time.Duration
+}
+
+type stats struct {
+	mu    sync.Mutex
+	byDef map[ID]*DefinitionStats
+}
+
+func newStats() *stats {
+	return &stats{byDef: make(map[ID]*DefinitionStats)}
+}
+
+func (s *stats) record(call Call, d time.Duration, err error) {
+	if call.Kind == MethodCall {
+		return // Method calls are a part of the service instantiation.
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ds, ok := s.byDef[call.Definition.ID()]
+	if !ok {
+		ds = &DefinitionStats{Definition: call.Definition}
+		s.byDef[call.Definition.ID()] = ds
+	}
+
+	switch call.Kind {
+	case ServiceCacheHit:
+		ds.CacheHits++
+		return
+	case ServiceInstantiation:
+		ds.Instantiations++
+	case FunctionCall:
+		ds.Executions++
+	}
+	ds.Duration += d
+	if err != nil {
+		ds.Errors++
+	}
+}
+
+func (s *stats) snapshot() map[ID]DefinitionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[ID]DefinitionStats, len(s.byDef))
+	for id, ds := range s.byDef {
+		snapshot[id] = *ds
+	}
+	return snapshot
+}
+
+// collectStats makes the container collect the runtime counters of the definitions.
+// It must be called before the container is used, see Config.Stats.
+func (c *Container) collectStats() {
+	if c.stats == nil {
+		c.stats = newStats()
+	}
+}
+
+// Stats returns the runtime counters of all definitions that have been used since the container was built.
+// Definitions that have never been used are not included.
+// The result is empty unless the container collects stats, see Config.Stats.
+func (c *Container) Stats() map[ID]DefinitionStats {
+	if c.stats == nil {
+		return map[ID]DefinitionStats{}
+	}
+	return c.stats.snapshot()
+}
+
+// StatsProvider is implemented by containers that collect runtime stats.
+type StatsProvider interface {
+	Stats() map[ID]DefinitionStats
+}
+
+// NewStatsVar returns an expvar variable that renders the current stats of the given container as JSON.
+// The stats are keyed by definition ID. Use expvar.Publish to expose it.
+func NewStatsVar(c StatsProvider) expvar.Var {
+	return expvar.Func(func() any {
+		vars := make(map[ID]statsVar)
+		for id, ds := range c.Stats() {
+			vars[id] = statsVar{
+				Definition:     fmt.Sprint(ds.Definition),
+				Instantiations: ds.Instantiations,
+				CacheHits:      ds.CacheHits,
+				Executions:     ds.Executions,
+				Errors:         ds.Errors,
+				DurationNs:     ds.Duration.Nanoseconds(),
+			}
+		}
+		return vars
+	})
+}
+
+type statsVar struct {
+	Definition     string `json:"definition"`
+	Instantiations uint64 `json:"instantiations"`
+	CacheHits      uint64 `json:"cache_hits"`
+	Executions     uint64 `json:"executions"`
+	Errors         uint64 `json:"errors"`
+	DurationNs     int64  `json:"duration_ns"`
+}
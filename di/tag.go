@@ -0,0 +1,68 @@
+package di
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Attrs are the attributes of a Tag.
+type Attrs map[string]any
+
+func (a Attrs) String() string {
+	strs := make([]string, 0, len(a))
+	for _, k := range slices.Sorted(maps.Keys(a)) {
+		strs = append(strs, fmt.Sprintf("%s=%v", k, a[k]))
+	}
+	return strings.Join(strs, ", ")
+}
+
+// Tag is a label with attributes. A definition can have multiple tags with the same name,
+// e.g. an event listener that listens to multiple events.
+// The name of a tag is also a label of the definition, so tagged definitions can be looked up by label.
+type Tag struct {
+	Name  Label
+	Attrs Attrs
+}
+
+func NewTag(name Label, attrs Attrs) Tag {
+	return Tag{Name: name, Attrs: attrs}
+}
+
+func (t Tag) String() string {
+	if len(t.Attrs) == 0 {
+		return t.Name.String()
+	}
+	return fmt.Sprintf("%s{%s}", t.Name, t.Attrs)
+}
+
+// TaggedService is a service together with the attributes of one of its tags.
+type TaggedService struct {
+	Svc   any
+	Attrs Attrs
+}
+
+// tagLabels returns the labels extended with the names of the tags.
+func tagLabels(labels []Label, tags []Tag) []Label {
+	if len(tags) == 0 {
+		return labels
+	}
+	all := slices.Clone(labels)
+	for _, tag := range tags {
+		if !slices.Contains(all, tag.Name) {
+			all = append(all, tag.Name)
+		}
+	}
+	return all
+}
+
+func tagsByName(tags []Tag, name Label) []Tag {
+	var matching []Tag
+	for _, tag := range tags {
+		if tag.Name == name {
+			matching = append(matching, tag)
+		}
+	}
+	return matching
+}
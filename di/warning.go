@@ -0,0 +1,23 @@
+package di
+
+import (
+	"fmt"
+)
+
+// Warning is a non-fatal issue found while building the container.
+// Unlike errors, warnings don't stop the build.
+type Warning struct {
+	// Pass is the name of the compiler pass that emitted the warning.
+	Pass    string
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Pass == "" {
+		return w.Message
+	}
+	return fmt.Sprintf("compiler pass (%s): %s", w.Pass, w.Message)
+}
+
+// WarningHandler is called for each warning, as soon as it's emitted.
+type WarningHandler func(Warning)
@@ -1,14 +1,27 @@
 package di_test
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/require"
 
 	di "github.com/michalkurzeja/godi/v2"
+	core "github.com/michalkurzeja/godi/v2/di"
+	"github.com/michalkurzeja/godi/v2/extras"
 )
 
 const constMethodArg = "const-method-arg"
@@ -682,7 +695,45 @@ func TestGodi(t *testing.T) {
 				require.Equal(t, "foo", got)
 			},
 		},
+		{
+			name: "shared services of outer scopes are instantiated once, even if resolved by type in a child scope",
+			build: func(b *di.Builder, refs *Refs) {
+				b.Services(
+					di.Svc(NewAppendableEcho[string]).MethodCall((*AppendableEcho[string]).Append, "foo"),
+					di.Svc(func(echo *AppendableEcho[string], _ string) *TestSvc { return &TestSvc{Args: []any{echo}} }).Children(
+						di.SvcVal("bar"),
+					),
+				)
+			},
+			assert: func(t *testing.T, c di.Container, refs *Refs) {
+				svc, err := di.SvcByType[*TestSvc](c)
+				require.NoError(t, err)
+				echo, err := di.SvcByType[*AppendableEcho[string]](c)
+				require.NoError(t, err)
+				require.Same(t, echo, svc.Args[0])
+				require.Equal(t, []string{"foo"}, svc.Args[0].(*AppendableEcho[string]).Echo())
+			},
+		},
 		// Methods
+		{
+			name: "method calls of non-shared services are executed on each created instance",
+			build: func(b *di.Builder, refs *Refs) {
+				b.Services(
+					di.Svc(NewAppendableEcho[string]).
+						MethodCall((*AppendableEcho[string]).Append, "foo").
+						NotShared(),
+				)
+			},
+			assert: func(t *testing.T, c di.Container, refs *Refs) {
+				echo1, err := di.SvcByType[*AppendableEcho[string]](c)
+				require.NoError(t, err)
+				echo2, err := di.SvcByType[*AppendableEcho[string]](c)
+				require.NoError(t, err)
+				require.NotSame(t, echo1, echo2)
+				require.Equal(t, []string{"foo"}, echo1.Echo())
+				require.Equal(t, []string{"foo"}, echo2.Echo())
+			},
+		},
 		{
 			name: "can register method calls",
 			build: func(b *di.Builder, refs *Refs) {
@@ -729,6 +780,60 @@ func TestGodi(t *testing.T) {
 				require.Equal(t, []string{"foo"}, svc.Echo())
 			},
 		},
+		{
+			name: "method calls can depend on services that depend on the service itself",
+			build: func(b *di.Builder, refs *Refs) {
+				b.Services(
+					di.Svc(func() *CyclicParent { return &CyclicParent{} }).
+						MethodCall((*CyclicParent).SetChild),
+					di.Svc(func(p *CyclicParent) *CyclicChild { return &CyclicChild{Parent: p} }),
+				)
+			},
+			assert: func(t *testing.T, c di.Container, refs *Refs) {
+				parent, err := di.SvcByType[*CyclicParent](c)
+				require.NoError(t, err)
+				require.NotNil(t, parent.Child)
+				require.Same(t, parent, parent.Child.Parent)
+
+				child, err := di.SvcByType[*CyclicChild](c)
+				require.NoError(t, err)
+				require.Same(t, parent.Child, child)
+			},
+		},
+		{
+			name: "named services that are nil are found",
+			build: func(b *di.Builder, refs *Refs) {
+				b.Services(
+					di.Svc(func() TestIface { return nil }).Name("nil"),
+					di.Svc(NewTestSvcSliceArgs).Children(
+						di.Svc(func(i TestIface) []string { return []string{fmt.Sprint(i)} }, di.Named[TestIface]("nil")),
+					),
+				)
+			},
+			assert: func(t *testing.T, c di.Container, refs *Refs) {
+				svc, err := di.SvcByName[TestIface](c, "nil")
+				require.NoError(t, err)
+				require.Nil(t, svc)
+
+				parent, err := di.SvcByType[*TestSvc](c)
+				require.NoError(t, err)
+				require.Equal(t, []any{"<nil>"}, parent.Args)
+			},
+		},
+		{
+			name:        "a circular dependency of factories fails the resolution if the cycle validation is skipped",
+			builderOpts: []di.BuilderOption{di.SkipCycleValidation()},
+			build: func(b *di.Builder, refs *Refs) {
+				b.Services(
+					di.Svc(func(*CyclicChild) *CyclicParent { return &CyclicParent{} }),
+					di.Svc(func(p *CyclicParent) *CyclicChild { return &CyclicChild{Parent: p} }),
+				)
+			},
+			assert: func(t *testing.T, c di.Container, refs *Refs) {
+				_, err := di.SvcByType[*CyclicParent](c)
+				require.ErrorContains(t, err, "circular dependency: service")
+			},
+		},
 		{
 			name: "can register method calls with Type arg",
 			build: func(b *di.Builder, refs *Refs) {
@@ -1191,25 +1296,36 @@ func TestGodi(t *testing.T) {
 			},
 		},
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
+	// The services must be resolved the same way, whether the resolution plans are precompiled or not.
+	modes := map[string][]di.BuilderOption{
+		"with resolution plans":    nil,
+		"without resolution plans": {di.SkipResolutionPlanning()},
+	}
+	for mode, modeOpts := range modes {
+		t.Run(mode, func(t *testing.T) {
 			t.Parallel()
 
-			refs := Refs{Svc: make(SvcRefs), Func: make(FuncRefs)}
-			builder := di.New(tt.builderOpts...)
-			if tt.build != nil {
-				tt.build(builder, &refs)
-			}
-
-			c, err := builder.Build()
-			if tt.assertBuildErr != nil {
-				tt.assertBuildErr(t, err)
-			} else {
-				require.NoError(t, err)
-			}
-
-			if tt.assert != nil {
-				tt.assert(t, c, &refs)
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					t.Parallel()
+
+					refs := Refs{Svc: make(SvcRefs), Func: make(FuncRefs)}
+					builder := di.New(append(slices.Clone(tt.builderOpts), modeOpts...)...)
+					if tt.build != nil {
+						tt.build(builder, &refs)
+					}
+
+					c, err := builder.Build()
+					if tt.assertBuildErr != nil {
+						tt.assertBuildErr(t, err)
+					} else {
+						require.NoError(t, err)
+					}
+
+					if tt.assert != nil {
+						tt.assert(t, c, &refs)
+					}
+				})
 			}
 		})
 	}
@@ -1232,6 +1348,2031 @@ func TestDI_Eager(t *testing.T) {
 	require.Equal(t, 0, lazyCounter)
 }
 
+func TestBuilder_Roots(t *testing.T) {
+	t.Run("keeps only definitions reachable from the roots", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		var eagerCalled bool
+
+		c, err := di.New().
+			Services(
+				di.SvcVal("foo"),
+				di.Svc(NewTestSvcStrArg).Bind(&ref),
+				di.Svc(func(int) float64 { return 0 }), // Has no int dependency, but it's not reachable, so it doesn't matter.
+				di.Svc(func() bool { eagerCalled = true; return true }).Eager(),
+			).
+			Roots(&ref).
+			Build()
+		require.NoError(t, err)
+		require.False(t, eagerCalled)
+
+		svc, err := di.SvcByRef[*TestSvc](c, ref)
+		require.NoError(t, err)
+		require.Equal(t, []any{"foo"}, svc.Args)
+	})
+	t.Run("can select roots by type and label", func(t *testing.T) {
+		t.Parallel()
+
+		var called, eagerCalled bool
+
+		c, err := di.New().
+			Services(
+				di.SvcVal("foo"),
+				di.SvcVal(42).Labels("root"),
+				di.Svc(NewTestSvcStrArg),
+				di.Svc(func(float32) []byte { return nil }), // Has no float32 dependency, but it's not a root.
+				di.Svc(func() bool { eagerCalled = true; return true }).Eager(),
+			).
+			Functions(
+				di.Func(func(int) { called = true }).Labels("root"),
+				di.Func(func(string) {}),
+			).
+			Roots(reflect.TypeFor[*TestSvc](), di.Label("root")).
+			Build()
+		require.NoError(t, err)
+		require.False(t, eagerCalled)
+
+		svc, err := di.SvcByType[*TestSvc](c)
+		require.NoError(t, err)
+		require.Equal(t, []any{"foo"}, svc.Args)
+
+		n, err := di.SvcByLabel[int](c, "root")
+		require.NoError(t, err)
+		require.Equal(t, 42, n)
+
+		_, err = di.ExecByLabel(c, "root")
+		require.NoError(t, err)
+		require.True(t, called)
+
+		require.Empty(t, c.GetServicesIDsByType(reflect.TypeFor[[]byte]()))
+		require.Empty(t, c.GetServicesIDsByType(reflect.TypeFor[bool]()))
+		_, err = di.ExecByType[func(string)](c)
+		require.ErrorContains(t, err, "not found")
+	})
+	t.Run("keeps eager definitions when requested", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		var eagerCalled bool
+
+		_, err := di.New().
+			Services(
+				di.SvcVal("foo").Bind(&ref),
+				di.Svc(func() bool { eagerCalled = true; return true }).Eager(),
+			).
+			Roots(&ref).
+			KeepEager().
+			Build()
+		require.NoError(t, err)
+		require.True(t, eagerCalled)
+	})
+	t.Run("returns an error when a root matches nothing", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+
+		_, err := di.New().
+			Roots(&ref, reflect.TypeFor[string](), di.Label("foo"), 42).
+			Build()
+		require.ErrorContains(t, err, "compilation failed: compiler pass (pruning) returned an error: failed to resolve roots: root <empty reference> matches no definitions\n"+
+			"root string matches no definitions\n"+
+			"root foo matches no definitions\n"+
+			"unsupported root 42 of type int")
+	})
+}
+
+func TestReportUnused(t *testing.T) {
+	var report strings.Builder
+
+	_, err := di.New(di.ReportUnused(&report)).
+		Services(
+			di.SvcVal("used"),
+			di.Svc(NewTestSvcStrArg).Labels(di.EntryPoint),
+			di.SvcVal(42),
+			di.SvcVal(true).Eager(),
+			di.SvcVal(new(TestIfaceImpl)),
+		).
+		Functions(
+			di.Func(func(TestIface) {}),
+		).
+		Build()
+	require.NoError(t, err)
+
+	require.Equal(t, "unused service int (factory: github.com/michalkurzeja/godi/v2.SvcVal[...].func1, scope: root)\n", report.String())
+}
+
+func TestBuilder_BuildWithReport(t *testing.T) {
+	var handled []di.Warning
+
+	_, report, err := di.New(
+		di.ReportUnused(nil),
+		di.WarningHandler(func(w di.Warning) { handled = append(handled, w) }),
+	).
+		Services(
+			di.SvcVal(42),
+		).
+		CompilerPasses(
+			core.NewCompilerPass("custom", core.Validation, core.CompilerOpFunc(func(builder *core.ContainerBuilder) error {
+				builder.Warn("something is %s", "fishy")
+				return nil
+			})),
+		).
+		BuildWithReport()
+	require.NoError(t, err)
+
+	want := []di.Warning{
+		{Pass: "custom", Message: "something is fishy"},
+		{Pass: "unused services report", Message: "unused service int (factory: github.com/michalkurzeja/godi/v2.SvcVal[...].func1, scope: root)"},
+	}
+	require.Equal(t, want, report.Warnings)
+	require.Equal(t, want, handled)
+	require.Equal(t, "compiler pass (custom): something is fishy", report.Warnings[0].String())
+}
+
+func TestBuildWarnings(t *testing.T) {
+	t.Run("reports unused bindings", func(t *testing.T) {
+		t.Parallel()
+
+		_, report, err := di.New().
+			Services(
+				di.SvcVal(new(TestIfaceImpl)),
+			).
+			Bindings(
+				di.BindType[TestIface, *TestIfaceImpl](),
+			).
+			BuildWithReport()
+		require.NoError(t, err)
+		require.Equal(t, []di.Warning{{
+			Pass:    "binding report",
+			Message: "unused binding of interface github.com/michalkurzeja/godi/v2_test.TestIface to github.com/michalkurzeja/godi/v2_test.(*TestIfaceImpl) (scope: root)",
+		}}, report.Warnings)
+	})
+	t.Run("reports bindings that pick one of multiple implementations", func(t *testing.T) {
+		t.Parallel()
+
+		_, report, err := di.New().
+			Services(
+				di.SvcVal(new(TestIfaceImpl)),
+				di.SvcVal(new(TestIfaceImpl2)),
+				di.Svc(NewTestSvcIfaceArg),
+			).
+			Bindings(
+				di.BindType[TestIface, *TestIfaceImpl](),
+			).
+			BuildWithReport()
+		require.NoError(t, err)
+		require.Len(t, report.Warnings, 1)
+		require.Equal(t, "binding report", report.Warnings[0].Pass)
+		require.Regexp(t, `^interface github.com/michalkurzeja/godi/v2_test.TestIface has multiple implementations \[\S+ \S+\], `+
+			`resolved by the binding to github.com/michalkurzeja/godi/v2_test.\(\*TestIfaceImpl\) \(scope: root\)$`, report.Warnings[0].Message)
+	})
+	t.Run("reports labels used once", func(t *testing.T) {
+		t.Parallel()
+
+		var registry di.SvcReference
+
+		_, report, err := di.New().
+			Services(
+				di.SvcVal("a").Labels("greeting"),
+				di.SvcVal("b").Labels("greeting"),
+				di.SvcVal("c").Labels("greetings"),
+				di.SvcVal(1.5).Labels("primary", di.EntryPoint),
+				di.Svc(func(f float64) int { return int(f) }, di.Type[float64]("primary")),
+				di.Svc(NewTestSvcSliceArgs).Bind(&registry),
+				di.SvcVal("d").Labels("collected"),
+			).
+			Functions(
+				di.Func(func() {}).Labels("startup"),
+			).
+			CompilerPasses(
+				di.CollectLabelled("collected", &registry, uint(0)),
+			).
+			BuildWithReport()
+		require.NoError(t, err)
+		require.Len(t, report.Warnings, 2)
+		require.Equal(t, "label report", report.Warnings[0].Pass)
+		require.Regexp(t, `^label greetings is used only once, by service string \(greetings\) \(factory: \S+, scope: root\)$`, report.Warnings[0].Message)
+		require.Regexp(t, `^label startup is used only once, by function \S+ \(startup\) \(scope: root\)$`, report.Warnings[1].Message)
+	})
+	t.Run("does not report the bindings of autowiring", func(t *testing.T) {
+		t.Parallel()
+
+		c, report, err := di.New().
+			Services(
+				di.SvcVal(new(TestIfaceImpl)),
+				di.Svc(NewTestSvcIfaceArg),
+				di.Svc(NewTestSvcIfaceSliceArgs),
+			).
+			BuildWithReport()
+		require.NoError(t, err)
+		require.Empty(t, report.Warnings)
+
+		builder, err := di.Derive(c)
+		require.NoError(t, err)
+		_, report, err = builder.BuildWithReport()
+		require.NoError(t, err)
+		require.Empty(t, report.Warnings)
+	})
+}
+
+type recordingInterceptor struct {
+	calls []string
+}
+
+func (i *recordingInterceptor) Before(call core.Call) {
+	i.calls = append(i.calls, "before "+call.String())
+}
+
+func (i *recordingInterceptor) After(call core.Call, _ time.Duration, err error) {
+	i.calls = append(i.calls, fmt.Sprintf("after %s (err: %v)", call, err))
+}
+
+func TestInterceptors(t *testing.T) {
+	t.Run("intercepts nested calls", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := new(recordingInterceptor)
+		report := core.NewTimingReport()
+
+		c, err := di.New(di.Interceptors(interceptor, report)).
+			Services(
+				di.SvcVal("foo"),
+				di.Svc(NewAppendableEcho[string]).
+					MethodCall((*AppendableEcho[string]).AppendVariadic),
+			).
+			Functions(
+				di.Func(func(*AppendableEcho[string]) error { return nil }),
+			).
+			Build()
+		require.NoError(t, err)
+		require.Empty(t, interceptor.calls)
+
+		_, err = di.ExecByType[func(*AppendableEcho[string]) error](c)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{
+			"before function call github.com/michalkurzeja/godi/v2_test.TestInterceptors.func1.1",
+			"before service instantiation github.com/michalkurzeja/godi/v2_test.(*AppendableEcho[string])",
+			"before method call github.com/michalkurzeja/godi/v2_test.(*AppendableEcho[...]).AppendVariadic of github.com/michalkurzeja/godi/v2_test.(*AppendableEcho[string])",
+			"before service instantiation string",
+			"after service instantiation string (err: <nil>)",
+			"after method call github.com/michalkurzeja/godi/v2_test.(*AppendableEcho[...]).AppendVariadic of github.com/michalkurzeja/godi/v2_test.(*AppendableEcho[string]) (err: <nil>)",
+			"after service instantiation github.com/michalkurzeja/godi/v2_test.(*AppendableEcho[string]) (err: <nil>)",
+			"after function call github.com/michalkurzeja/godi/v2_test.TestInterceptors.func1.1 (err: <nil>)",
+		}, interceptor.calls)
+
+		timings := report.Timings()
+		require.Len(t, timings, 4)
+		require.Equal(t, core.FunctionCall, timings[3].Call.Kind)
+		require.GreaterOrEqual(t, timings[3].Duration, timings[2].Duration)
+
+		var out strings.Builder
+		report.Print(&out)
+		lines := strings.Split(out.String(), "\n")
+		require.Regexp(t, `^DURATION\s+CALL\s+SCOPE\s+ERROR$`, lines[0])
+		require.Regexp(t, `^\S+\s+function call github.com/michalkurzeja/godi/v2_test.TestInterceptors.func1.1\s+root\s+-$`, lines[1])
+	})
+	t.Run("receives errors of failed calls", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := new(recordingInterceptor)
+
+		c, err := di.New(di.Interceptors(interceptor)).
+			Services(
+				di.Svc(func() (int, error) { return 0, errors.New("oops") }),
+			).
+			Build()
+		require.NoError(t, err)
+
+		_, err = di.SvcByType[int](c)
+		require.ErrorContains(t, err, "oops")
+		require.Equal(t, []string{
+			"before service instantiation int",
+			"after service instantiation int (err: failed to execute factory for service int: oops)",
+		}, interceptor.calls)
+	})
+	t.Run("links nested calls to the calls that triggered them", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := new(capturingInterceptor)
+
+		c, err := di.New(di.Interceptors(interceptor)).
+			Services(
+				di.SvcVal("foo"),
+				di.Svc(NewTestSvcStrArg),
+			).
+			Build()
+		require.NoError(t, err)
+
+		_, err = di.SvcByType[*TestSvc](c)
+		require.NoError(t, err)
+
+		require.Len(t, interceptor.calls, 2)
+		require.NotZero(t, interceptor.calls[0].ID)
+		require.Zero(t, interceptor.calls[0].Parent)
+		require.Equal(t, interceptor.calls[0].ID, interceptor.calls[1].Parent)
+		require.Equal(t, context.Background(), interceptor.calls[0].Context)
+	})
+	t.Run("passes the context of async resolutions", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := new(capturingInterceptor)
+
+		c, err := di.New(di.Interceptors(interceptor)).
+			Services(di.SvcVal("foo")).
+			Build()
+		require.NoError(t, err)
+
+		type ctxKey struct{}
+		ctx := context.WithValue(t.Context(), ctxKey{}, "value")
+		require.NoError(t, (<-di.SvcByTypeAsync[string](ctx, c)).Err)
+
+		require.Len(t, interceptor.calls, 1)
+		require.Equal(t, "value", interceptor.calls[0].Context.Value(ctxKey{}))
+	})
+	t.Run("calls After of panicking calls", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := new(recordingInterceptor)
+
+		c, err := di.New(di.Interceptors(interceptor)).
+			Services(
+				di.Svc(func() int { panic("oops") }),
+			).
+			Build()
+		require.NoError(t, err)
+
+		require.PanicsWithValue(t, "oops", func() { _, _ = di.SvcByType[int](c) })
+		require.Equal(t, []string{
+			"before service instantiation int",
+			"after service instantiation int (err: service instantiation int panicked)",
+		}, interceptor.calls)
+	})
+	t.Run("logs calls with slog", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey || a.Key == "duration" {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+
+		_, err := di.New(di.Interceptors(core.NewSlogInterceptor(logger, slog.LevelDebug))).
+			Services(
+				di.SvcVal(42).Eager(),
+			).
+			Build()
+		require.NoError(t, err)
+
+		require.Equal(t, "level=DEBUG msg=\"service instantiation\" definition=int scope=root lazy=false\n", out.String())
+	})
+}
+
+func TestNamedServices(t *testing.T) {
+	t.Run("retrieves and references services by name", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := di.New().
+			Services(
+				di.SvcVal("primary").Name("db.primary"),
+				di.SvcVal("replica").Name("db.replica"),
+				di.Svc(NewTestSvcStrArg, di.Named[string]("db.replica")).Children(
+					di.Svc(func(s string) []string { return []string{s} }, di.Named[string]("db.primary")),
+				),
+			).
+			Build()
+		require.NoError(t, err)
+
+		primary, err := di.SvcByName[string](c, "db.primary")
+		require.NoError(t, err)
+		require.Equal(t, "primary", primary)
+
+		svc, err := di.SvcByType[*TestSvc](c)
+		require.NoError(t, err)
+		require.Equal(t, []any{"replica"}, svc.Args)
+
+		_, err = di.SvcByName[string](c, "db.unknown")
+		require.EqualError(t, err, `service named "db.unknown" not found`)
+		var notFound *di.ServiceNameNotFoundError
+		require.ErrorAs(t, err, &notFound)
+		require.Equal(t, "db.unknown", notFound.Name)
+	})
+	t.Run("fails on duplicate names in a scope chain", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := di.New().
+			Services(
+				di.SvcVal("primary").Name("db"),
+				di.SvcVal(42).Name("db"),
+				di.SvcVal(1.0).Children(
+					di.SvcVal(true).Name("db"),
+					di.SvcVal([]byte("a")).Name("sibling"),
+				),
+				di.SvcVal(uint(2)).Children(
+					di.SvcVal([]byte("b")).Name("sibling"), // Sibling scopes don't clash.
+				),
+			).
+			Build()
+		require.Error(t, err)
+		require.Regexp(t, `duplicate service name "db": string \(factory: \S+, scope: root\) and int \(factory: \S+, scope: root\)`, err.Error())
+		require.Regexp(t, `duplicate service name "db": int \(factory: \S+, scope: root\) and bool \(factory: \S+, scope: float64\)`, err.Error())
+		require.NotContains(t, err.Error(), `duplicate service name "sibling"`)
+	})
+	t.Run("fails on unknown or mistyped names", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := di.New().
+			Services(
+				di.SvcVal(42).Name("answer"),
+				di.Svc(NewTestSvcStrArg, di.Named[string]("answer")),
+				di.Svc(func(int) []int { return nil }, di.Named[int]("question")),
+			).
+			Build()
+		require.ErrorContains(t, err, `service named "answer" should be assignable to type string, got int`)
+		require.ErrorContains(t, err, `no service found with name "question"`)
+	})
+}
+
+func TestDeterministicIDs(t *testing.T) {
+	t.Parallel()
+
+	var child1, child2 di.SvcReference
+
+	build := func() (di.Container, di.SvcReference, di.FuncReference) {
+		var ref di.SvcReference
+		var fnRef di.FuncReference
+
+		c, err := di.New(di.DeterministicIDs()).
+			Services(
+				di.SvcVal("foo"),
+				di.SvcVal("bar").Bind(&ref),
+				di.SvcVal(42).Children(
+					di.SvcVal("baz").Bind(&child1),
+				),
+				di.SvcVal(43).Children(
+					di.SvcVal("baz").Bind(&child2), // Same scope path, factory and index as the other "baz".
+				),
+			).
+			Functions(
+				di.Func(func(s []string) {}).Bind(&fnRef),
+			).
+			Build()
+		require.NoError(t, err)
+
+		return c, ref, fnRef
+	}
+
+	c1, ref1, fnRef1 := build()
+	c2, ref2, fnRef2 := build()
+
+	require.Equal(t, ref1.SvcID(), ref2.SvcID())
+	require.Equal(t, fnRef1.FuncID(), fnRef2.FuncID())
+	require.Equal(t, c1.GetServicesIDsByType(reflect.TypeFor[string]()), c2.GetServicesIDsByType(reflect.TypeFor[string]()))
+
+	var out1, out2 strings.Builder
+	c1.Print(&out1)
+	c2.Print(&out2)
+	require.Equal(t, out1.String(), out2.String())
+
+	ids := c1.GetServicesIDsByType(reflect.TypeFor[string]())
+	require.Len(t, ids, 2)
+	require.Len(t, lo.Uniq(append(ids, fnRef1.FuncID(), child1.SvcID(), child2.SvcID())), 5)
+
+	svc, err := di.SvcByRef[string](c1, ref1)
+	require.NoError(t, err)
+	require.Equal(t, "bar", svc)
+}
+
+type Format string
+
+type TestIfaceImpl2 struct{}
+
+func (i *TestIfaceImpl2) TestIfaceMethod() {}
+
+func TestMapOf(t *testing.T) {
+	t.Run("injects keyed services", func(t *testing.T) {
+		t.Parallel()
+
+		csvImpl, jsonImpl := new(TestIfaceImpl), new(TestIfaceImpl2)
+
+		c, err := di.New().
+			Services(
+				di.SvcVal(csvImpl).Key("csv"),
+				di.SvcVal(jsonImpl).Name("json"), // The name is used as the key.
+				di.SvcVal(new(TestIfaceImpl)),    // Not keyed, skipped.
+				di.Svc(func(m map[Format]TestIface) map[Format]TestIface { return m }, di.MapOf[Format, TestIface](nil)),
+				di.Svc(func(m map[int]TestIface) map[int]TestIface { return m }, di.MapOf[int, TestIface](func(key string) int { return len(key) }, "only-csv")),
+			).
+			Build()
+		require.ErrorContains(t, err, "no keyed services found for type github.com/michalkurzeja/godi/v2_test.TestIface with label only-csv")
+		require.Nil(t, c)
+
+		c, err = di.New().
+			Services(
+				di.SvcVal(csvImpl).Key("csv").Labels("only-csv"),
+				di.SvcVal(jsonImpl).Name("json"),
+				di.SvcVal(new(TestIfaceImpl)),
+				di.Svc(func(m map[Format]TestIface) map[Format]TestIface { return m }, di.MapOf[Format, TestIface](nil)),
+				di.Svc(func(m map[int]TestIface) map[int]TestIface { return m }, di.MapOf[int, TestIface](func(key string) int { return len(key) }, "only-csv")),
+			).
+			Build()
+		require.NoError(t, err)
+
+		byFormat, err := di.SvcByType[map[Format]TestIface](c)
+		require.NoError(t, err)
+		require.Equal(t, map[Format]TestIface{"csv": csvImpl, "json": jsonImpl}, byFormat)
+
+		byLen, err := di.SvcByType[map[int]TestIface](c)
+		require.NoError(t, err)
+		require.Equal(t, map[int]TestIface{3: csvImpl}, byLen)
+	})
+	t.Run("autowires maps by type", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := di.New().
+			Services(
+				di.SvcVal("foo").Key("a"),
+				di.SvcVal(map[string]int{"x": 1}),
+				di.Svc(func(ints map[string]int) *TestSvc { return &TestSvc{Args: []any{ints}} }),
+			).
+			Build()
+		require.NoError(t, err)
+
+		svc, err := di.SvcByType[*TestSvc](c)
+		require.NoError(t, err)
+		require.Equal(t, []any{map[string]int{"x": 1}}, svc.Args)
+
+		_, err = di.New().
+			Services(
+				di.SvcVal("foo").Key("a"),
+				di.Svc(func(map[string]string) int { return 0 }), // Keyed services are injected only with MapOf.
+			).
+			Build()
+		require.ErrorContains(t, err, "no services found for type map[string]string")
+	})
+	t.Run("fails on duplicate keys", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := di.New().
+			Services(
+				di.SvcVal("foo").Key("a"),
+				di.SvcVal("bar").Name("a"),
+				di.Svc(func(map[string]string) int { return 0 }, di.MapOf[string, string](nil)),
+			).
+			Build()
+		require.ErrorContains(t, err, "duplicate map key a: string and string")
+	})
+}
+
+func TestTags(t *testing.T) {
+	t.Parallel()
+
+	var fromPass []string
+
+	c, err := di.New().
+		Services(
+			di.SvcVal("created").
+				Tag("event.listener", di.Attrs{"event": "user.created", "priority": 10}).
+				Tag("event.listener", di.Attrs{"event": "user.updated"}, di.Attrs{"priority": 5}),
+			di.SvcVal("deleted").Tag("event.listener", di.Attrs{"event": "user.deleted"}),
+			di.SvcVal("other").Labels("event.listener"), // Labelled, but not tagged.
+		).
+		Functions(
+			di.Func(func() {}).Tag("cron", di.Attrs{"schedule": "@daily"}),
+		).
+		CompilerPasses(
+			core.NewCompilerPass("registry", core.Automation, core.CompilerOpFunc(func(builder *core.ContainerBuilder) error {
+				for _, def := range builder.ServiceDefinitionsSeq() {
+					for _, tag := range def.TagsByName("event.listener") {
+						fromPass = append(fromPass, tag.String())
+					}
+				}
+				for _, def := range builder.FunctionDefinitionsSeq() {
+					for _, tag := range def.Tags() {
+						fromPass = append(fromPass, tag.String())
+					}
+				}
+				return nil
+			})),
+		).
+		Build()
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"event.listener{event=user.created, priority=10}",
+		"event.listener{event=user.updated, priority=5}",
+		"event.listener{event=user.deleted}",
+		"cron{schedule=@daily}",
+	}, fromPass)
+
+	listeners, err := di.SvcsByTag[string](c, "event.listener")
+	require.NoError(t, err)
+	require.Equal(t, []di.Tagged[string]{
+		{Svc: "created", Attrs: di.Attrs{"event": "user.created", "priority": 10}},
+		{Svc: "created", Attrs: di.Attrs{"event": "user.updated", "priority": 5}},
+		{Svc: "deleted", Attrs: di.Attrs{"event": "user.deleted"}},
+	}, listeners)
+
+	// Tag names are labels too.
+	labelled, err := di.SvcsByLabel[string](c, "event.listener")
+	require.NoError(t, err)
+	require.Equal(t, []string{"created", "deleted", "other"}, labelled)
+}
+
+func TestPriority(t *testing.T) {
+	t.Run("orders slices by priority and then by registration", func(t *testing.T) {
+		t.Parallel()
+
+		type Reversed []string
+
+		c, err := di.New().
+			Services(
+				di.SvcVal("low").Priority(-5).Labels("mw"),
+				di.SvcVal("first").Labels("mw"),
+				di.SvcVal("high").Priority(10).Labels("mw"),
+				di.SvcVal("second").Labels("mw"),
+				di.Svc(func(s []string) *TestSvc { return &TestSvc{Args: []any{s}} }),
+				di.Svc(func(s []string) Reversed { return s }, di.SliceOf[string]("mw").Reverse()),
+			).
+			Build()
+		require.NoError(t, err)
+
+		want := []string{"high", "first", "second", "low"}
+
+		svc, err := di.SvcByType[*TestSvc](c)
+		require.NoError(t, err)
+		require.Equal(t, []any{want}, svc.Args)
+
+		labelled, err := di.SvcsByLabel[string](c, "mw")
+		require.NoError(t, err)
+		require.Equal(t, want, labelled)
+
+		byType, err := di.SvcsByType[string](c)
+		require.NoError(t, err)
+		require.Equal(t, want, byType)
+
+		reversed, err := di.SvcByType[Reversed](c)
+		require.NoError(t, err)
+		require.Equal(t, Reversed{"low", "second", "first", "high"}, reversed)
+
+		var out strings.Builder
+		c.Print(&out)
+		require.Contains(t, out.String(), "Priority:\t10\n")
+		require.Contains(t, out.String(), " - mw (reversed)\n\t1. string (mw)\n\t2. string (mw)\n\t3. string (mw)\n\t4. string (mw)\n")
+	})
+	t.Run("keeps the order of explicitly requested services", func(t *testing.T) {
+		t.Parallel()
+
+		var low, high di.SvcReference
+		c, err := di.New().
+			Services(
+				di.SvcVal("low").Priority(-5).Bind(&low),
+				di.SvcVal("high").Priority(10).Bind(&high),
+			).
+			Build()
+		require.NoError(t, err)
+
+		svcs, err := c.GetServices(low.SvcID(), high.SvcID())
+		require.NoError(t, err)
+		require.Equal(t, []any{"low", "high"}, svcs)
+	})
+	t.Run("orders interface implementations by priority", func(t *testing.T) {
+		t.Parallel()
+
+		impl1, impl2 := new(TestIfaceImpl), new(TestIfaceImpl2)
+
+		c, err := di.New().
+			Services(
+				di.SvcVal(impl1),
+				di.SvcVal(impl2).Priority(1),
+				di.Svc(func(impls []TestIface) *TestSvc { return &TestSvc{Args: []any{impls}} }),
+			).
+			Build()
+		require.NoError(t, err)
+
+		svc, err := di.SvcByType[*TestSvc](c)
+		require.NoError(t, err)
+		require.Equal(t, []any{[]TestIface{impl2, impl1}}, svc.Args)
+	})
+	t.Run("fails to reverse a non-slice argument", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := di.New().
+			Services(
+				di.Svc(NewTestSvcStrArg, di.Type[string]().Reverse()),
+			).
+			Build()
+		require.ErrorContains(t, err, "cannot reverse argument string: not a slice")
+	})
+}
+
+func TestCompilerPasses(t *testing.T) {
+	record := func(order *[]string, name string) core.CompilerOp {
+		return core.CompilerOpFunc(func(*core.ContainerBuilder) error {
+			*order = append(*order, name)
+			return nil
+		})
+	}
+
+	t.Run("runs passes by stage and priority", func(t *testing.T) {
+		t.Parallel()
+
+		var order []string
+		_, err := di.New().
+			CompilerPasses(
+				core.NewCompilerPass("validation", core.Validation, record(&order, "validation")),
+				core.NewCompilerPass("low", core.PreAutomation, record(&order, "low")).WithPriority(-1),
+				core.NewCompilerPass("default", core.PreAutomation, record(&order, "default")),
+				core.NewCompilerPass("high", core.PreAutomation, record(&order, "high")).WithPriority(1),
+				core.NewCompilerPass("default 2", core.PreAutomation, record(&order, "default 2")),
+			).
+			Build()
+		require.NoError(t, err)
+		require.Equal(t, []string{"high", "default", "default 2", "low", "validation"}, order)
+	})
+	t.Run("runs passes by their constraints", func(t *testing.T) {
+		t.Parallel()
+
+		var order []string
+		var passes core.Passes
+		_, err := di.New().
+			CompilerPasses(
+				core.NewCompilerPass("a", core.Automation, record(&order, "a")).After("b").WithPriority(2),
+				core.NewCompilerPass("b", core.Automation, record(&order, "b")),
+				core.NewCompilerPass("c", core.Automation, record(&order, "c")).Before("interface binding"),
+				core.NewCompilerPass("d", core.Automation, record(&order, "d")).After("autowiring").WithPriority(1),
+				core.NewCompilerPass("e", core.Automation, record(&order, "e")).After("missing"),
+				core.NewCompilerPass("listing", core.PostFinalization, core.CompilerOpFunc(func(builder *core.ContainerBuilder) (err error) {
+					passes, err = builder.Compiler().Passes()
+					return err
+				})),
+			).
+			Build()
+		require.NoError(t, err)
+		require.Equal(t, []string{"b", "a", "c", "d", "e"}, order)
+		require.Equal(t, `1. b (stage: automation, priority: 0)
+2. a (stage: automation, priority: 2)
+3. c (stage: automation, priority: 0)
+4. interface binding (stage: automation, priority: 0)
+5. autowiring (stage: automation, priority: 0)
+6. d (stage: automation, priority: 1)
+7. e (stage: automation, priority: 0)
+8. name validation (stage: validation, priority: 0)
+9. argument validation (stage: validation, priority: 0)
+10. cycle validation (stage: validation, priority: 0)
+11. resolution planning (stage: finalization, priority: 0)
+12. eager initialization (stage: finalization, priority: 0)
+13. binding report (stage: post-finalization, priority: 0)
+14. label report (stage: post-finalization, priority: 0)
+15. listing (stage: post-finalization, priority: 0)
+`, passes.String())
+	})
+	t.Run("fails on cyclic constraints", func(t *testing.T) {
+		t.Parallel()
+
+		var order []string
+		_, err := di.New().
+			CompilerPasses(
+				core.NewCompilerPass("a", core.PreValidation, record(&order, "a")).After("b"),
+				core.NewCompilerPass("b", core.PreValidation, record(&order, "b")).After("c"),
+				core.NewCompilerPass("c", core.PreValidation, record(&order, "c")).After("a"),
+				core.NewCompilerPass("d", core.PreValidation, record(&order, "d")),
+			).
+			Build()
+		require.EqualError(t, err, "compilation failed: conflicting compiler pass constraints: cyclic order of passes in stage pre-validation: a, b, c")
+		require.Empty(t, order)
+	})
+	t.Run("fails on constraints across stages", func(t *testing.T) {
+		t.Parallel()
+
+		var order []string
+		_, err := di.New().
+			CompilerPasses(
+				core.NewCompilerPass("a", core.PreAutomation, record(&order, "a")).After("autowiring"),
+				core.NewCompilerPass("b", core.Finalization, record(&order, "b")).Before("argument validation"),
+				core.NewCompilerPass("c", core.Finalization, record(&order, "c")).After("argument validation"),
+			).
+			Build()
+		require.EqualError(t, err, "compilation failed: conflicting compiler pass constraints: "+
+			"pass a (stage: pre-automation) cannot run after autowiring (stage: automation)\n"+
+			"pass b (stage: finalization) cannot run before argument validation (stage: validation)")
+		require.Empty(t, order)
+	})
+}
+
+func TestCollectLabelled(t *testing.T) {
+	labelled := func() []*di.ServiceDefinitionBuilder {
+		return []*di.ServiceDefinitionBuilder{
+			di.SvcVal("a").Labels("collect"),
+			di.SvcVal("b").Labels("collect").Priority(1),
+			di.SvcVal("c"),
+		}
+	}
+
+	t.Run("adds a method call with the labelled services", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		c, err := di.New().
+			Services(labelled()...).
+			Services(di.Svc(NewTestSvcNoArgs).Bind(&ref)).
+			CompilerPasses(di.CollectLabelled("collect", &ref, (*TestSvc).AddArgsSlice)).
+			Build()
+		require.NoError(t, err)
+
+		svc, err := di.SvcByRef[*TestSvc](c, ref)
+		require.NoError(t, err)
+		require.Equal(t, []any{"b", "a"}, svc.Args)
+	})
+	t.Run("orders the labelled services", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		c, err := di.New().
+			Services(labelled()...).
+			Services(di.Svc(NewTestSvcNoArgs).Bind(&ref)).
+			CompilerPasses(di.CollectLabelled("collect", &ref, (*TestSvc).AddArgsVariadic, di.OrderBy(func(a, b *core.ServiceDefinition) int {
+				return cmp.Compare(a.Priority(), b.Priority())
+			}))).
+			Build()
+		require.NoError(t, err)
+
+		svc, err := di.SvcByRef[*TestSvc](c, ref)
+		require.NoError(t, err)
+		require.Equal(t, []any{"a", "b"}, svc.Args)
+	})
+	t.Run("appends to an existing method call", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		c, err := di.New().
+			Services(labelled()...).
+			Services(di.Svc(NewTestSvcNoArgs).Bind(&ref).MethodCall((*TestSvc).AddArgsVariadic, di.Val("x"))).
+			CompilerPasses(di.CollectLabelled("collect", &ref, (*TestSvc).AddArgsVariadic)).
+			Build()
+		require.NoError(t, err)
+
+		svc, err := di.SvcByRef[*TestSvc](c, ref)
+		require.NoError(t, err)
+		require.Equal(t, []any{"x", "b", "a"}, svc.Args)
+	})
+	t.Run("appends to the factory slot", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		c, err := di.New().
+			Services(labelled()...).
+			Services(di.Svc(NewTestSvcSliceArgs).Bind(&ref)).
+			CompilerPasses(di.CollectLabelled("collect", &ref, 0)).
+			Build()
+		require.NoError(t, err)
+
+		svc, err := di.SvcByRef[*TestSvc](c, ref)
+		require.NoError(t, err)
+		require.Equal(t, []any{"b", "a"}, svc.Args)
+	})
+	t.Run("skips the target", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		c, err := di.New().
+			Services(labelled()...).
+			Services(di.Svc(NewTestSvcNoArgs).Bind(&ref).Labels("collect")).
+			CompilerPasses(di.CollectLabelled("collect", &ref, (*TestSvc).AddArgsSlice)).
+			Build()
+		require.NoError(t, err)
+
+		svc, err := di.SvcByRef[*TestSvc](c, ref)
+		require.NoError(t, err)
+		require.Equal(t, []any{"b", "a"}, svc.Args)
+	})
+	t.Run("does nothing if no service is labelled", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		c, err := di.New().
+			Services(di.Svc(NewTestSvcNoArgs).Bind(&ref)).
+			CompilerPasses(di.CollectLabelled("collect", &ref, (*TestSvc).AddArgsSlice)).
+			Build()
+		require.NoError(t, err)
+
+		svc, err := di.SvcByRef[*TestSvc](c, ref)
+		require.NoError(t, err)
+		require.Empty(t, svc.Args)
+	})
+	t.Run("fails if required and no service is labelled", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		_, err := di.New().
+			Services(di.Svc(NewTestSvcNoArgs).Bind(&ref)).
+			CompilerPasses(di.CollectLabelled("collect", &ref, (*TestSvc).AddArgsSlice, di.Required())).
+			Build()
+		require.ErrorContains(t, err, "no services labelled collect found for github.com/michalkurzeja/godi/v2_test.(*TestSvc)")
+	})
+	t.Run("fails on a mismatching method", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		_, err := di.New().
+			Services(labelled()...).
+			Services(di.Svc(NewTestSvcNoArgs).Bind(&ref)).
+			CompilerPasses(di.CollectLabelled("collect", &ref, (*TestSvc).AddArgStr)).
+			Build()
+		require.ErrorContains(t, err, "failed to collect services labelled collect into github.com/michalkurzeja/godi/v2_test.(*TestSvc): "+
+			"method github.com/michalkurzeja/godi/v2_test.(*TestSvc).AddArgStr must accept a single slice argument")
+	})
+	t.Run("fails on an invalid target", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		_, err := di.New().
+			Services(labelled()...).
+			CompilerPasses(di.CollectLabelled("collect", &ref, (*TestSvc).AddArgsSlice)).
+			Build()
+		require.ErrorContains(t, err, "cannot collect services labelled collect: empty target reference")
+	})
+}
+
+func TestBuilder_Clone(t *testing.T) {
+	t.Run("builds independent containers", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		var passRuns int
+		base := di.New().
+			Services(di.Svc(NewTestSvcStrArg).Bind(&ref)).
+			CompilerPasses(core.NewCompilerPass("count", core.PreAutomation, core.CompilerOpFunc(func(*core.ContainerBuilder) error {
+				passRuns++
+				return nil
+			})))
+
+		clone1, err := base.Clone()
+		require.NoError(t, err)
+		clone2, err := base.Clone()
+		require.NoError(t, err)
+
+		for builder, val := range map[*di.Builder]string{base: "base", clone1: "one", clone2: "two"} {
+			c, err := builder.Services(di.SvcVal(val)).Build()
+			require.NoError(t, err)
+
+			svc, err := di.SvcByRef[*TestSvc](c, ref)
+			require.NoError(t, err)
+			require.Equal(t, []any{val}, svc.Args)
+		}
+		require.Equal(t, 3, passRuns)
+	})
+	t.Run("compiler passes of a clone modify the definitions of the clone", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		base := di.New().
+			Services(
+				di.Svc(NewTestSvcNoArgs).Bind(&ref),
+				di.SvcVal("a").Labels("collect"),
+			).
+			CompilerPasses(di.CollectLabelled("collect", &ref, (*TestSvc).AddArgsSlice))
+
+		clone, err := base.Clone()
+		require.NoError(t, err)
+
+		for _, builder := range []*di.Builder{clone, base} {
+			c, err := builder.Build()
+			require.NoError(t, err)
+
+			svc, err := di.SvcByRef[*TestSvc](c, ref)
+			require.NoError(t, err)
+			require.Equal(t, []any{"a"}, svc.Args)
+		}
+	})
+	t.Run("references added to a clone resolve to the services of the clone", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		var instantiations int
+		base := di.New().
+			Services(di.Svc(func() *TestSvc {
+				instantiations++
+				return &TestSvc{}
+			}).Bind(&ref))
+
+		clone, err := base.Clone()
+		require.NoError(t, err)
+		c, err := clone.
+			Services(di.Svc(func(svc *TestSvc) []*TestSvc { return []*TestSvc{svc} }, di.Ref(&ref))).
+			Build()
+		require.NoError(t, err)
+
+		svcs, err := di.SvcByType[[]*TestSvc](c)
+		require.NoError(t, err)
+		svc, err := di.SvcByRef[*TestSvc](c, ref)
+		require.NoError(t, err)
+		require.Same(t, svc, svcs[0])
+		require.Equal(t, 1, instantiations)
+	})
+	t.Run("fails to clone an invalid builder", func(t *testing.T) {
+		t.Parallel()
+
+		builder := di.New().Services(di.Svc("not a factory"))
+
+		_, err := builder.Clone()
+		require.ErrorContains(t, err, "factory kind must be func, got string")
+
+		_, err = builder.Build()
+		require.ErrorContains(t, err, "factory kind must be func, got string")
+	})
+}
+
+func TestDerive(t *testing.T) {
+	build := func(t *testing.T, instantiations *atomic.Int32) (c di.Container, strRef, svcRef, implRef di.SvcReference) {
+		t.Helper()
+
+		c, err := di.New().
+			Services(
+				di.SvcVal("parent").Bind(&strRef),
+				di.Svc(NewTestSvcStrArg).Bind(&svcRef),
+				di.Svc(func() *TestIfaceImpl {
+					instantiations.Add(1)
+					return new(TestIfaceImpl)
+				}).Bind(&implRef),
+			).
+			Build()
+		require.NoError(t, err)
+
+		_, err = di.SvcByRef[*TestSvc](c, svcRef)
+		require.NoError(t, err)
+		_, err = di.SvcByRef[*TestIfaceImpl](c, implRef)
+		require.NoError(t, err)
+
+		return c, strRef, svcRef, implRef
+	}
+
+	t.Run("derives a modified container", func(t *testing.T) {
+		t.Parallel()
+
+		var instantiations atomic.Int32
+		c, strRef, svcRef, implRef := build(t, &instantiations)
+
+		builder, err := di.Derive(c)
+		require.NoError(t, err)
+		derived, err := builder.
+			Services(di.SvcVal("derived")).
+			CompilerPasses(extras.RemoveSvc(&strRef)).
+			Build()
+		require.NoError(t, err)
+
+		svc, err := di.SvcByRef[*TestSvc](derived, svcRef)
+		require.NoError(t, err)
+		require.Equal(t, []any{"derived"}, svc.Args)
+
+		parentImpl, err := di.SvcByRef[*TestIfaceImpl](c, implRef)
+		require.NoError(t, err)
+		derivedImpl, err := di.SvcByRef[*TestIfaceImpl](derived, implRef)
+		require.NoError(t, err)
+		require.NotSame(t, parentImpl, derivedImpl)
+		require.Equal(t, int32(2), instantiations.Load())
+
+		svc, err = di.SvcByRef[*TestSvc](c, svcRef)
+		require.NoError(t, err)
+		require.Equal(t, []any{"parent"}, svc.Args)
+	})
+	t.Run("reuses unchanged instances", func(t *testing.T) {
+		t.Parallel()
+
+		var instantiations atomic.Int32
+		c, strRef, svcRef, implRef := build(t, &instantiations)
+
+		builder, err := di.Derive(c, di.ReuseInstances())
+		require.NoError(t, err)
+		derived, err := builder.
+			Services(di.SvcVal("derived")).
+			CompilerPasses(extras.RemoveSvc(&strRef)).
+			Build()
+		require.NoError(t, err)
+
+		parentSvc, err := di.SvcByRef[*TestSvc](c, svcRef)
+		require.NoError(t, err)
+		derivedSvc, err := di.SvcByRef[*TestSvc](derived, svcRef)
+		require.NoError(t, err)
+		require.NotSame(t, parentSvc, derivedSvc, "dependency changed, service must not be reused")
+		require.Equal(t, []any{"derived"}, derivedSvc.Args)
+
+		parentImpl, err := di.SvcByRef[*TestIfaceImpl](c, implRef)
+		require.NoError(t, err)
+		derivedImpl, err := di.SvcByRef[*TestIfaceImpl](derived, implRef)
+		require.NoError(t, err)
+		require.Same(t, parentImpl, derivedImpl)
+		require.Equal(t, int32(1), instantiations.Load())
+	})
+	t.Run("does not reuse instances of services with a replaced factory", func(t *testing.T) {
+		t.Parallel()
+
+		var parentRef, derivedRef di.SvcReference
+		c, err := di.New().
+			Services(
+				di.SvcVal("parent").Bind(&parentRef),
+				di.SvcVal("derived").Bind(&derivedRef),
+			).
+			Build()
+		require.NoError(t, err)
+		_, err = di.SvcByRef[string](c, parentRef)
+		require.NoError(t, err)
+
+		// Both factories are closures of the same function literal, so they share the code.
+		derived, err := c.(*core.Container).Derive(func(builder *core.ContainerBuilder) error {
+			def, ok := builder.RootScope().GetServiceDefinition(parentRef.SvcID())
+			require.True(t, ok)
+			replacement, ok := builder.RootScope().GetServiceDefinition(derivedRef.SvcID())
+			require.True(t, ok)
+			def.SetFactory(replacement.Factory())
+			return nil
+		}, core.ReuseInstances())
+		require.NoError(t, err)
+
+		svc, err := di.SvcByRef[string](derived, parentRef)
+		require.NoError(t, err)
+		require.Equal(t, "derived", svc)
+	})
+	t.Run("derives with the core container", func(t *testing.T) {
+		t.Parallel()
+
+		var instantiations atomic.Int32
+		c, _, svcRef, _ := build(t, &instantiations)
+
+		derived, err := c.(*core.Container).Derive(func(builder *core.ContainerBuilder) error {
+			def, ok := builder.RootScope().GetServiceDefinition(svcRef.SvcID())
+			require.True(t, ok)
+			return def.Factory().Args().SetSlot(core.NewSlottedArg(core.NewLiteralArg("literal"), 0))
+		}, core.ReuseInstances())
+		require.NoError(t, err)
+
+		svc, err := di.SvcByRef[*TestSvc](derived, svcRef)
+		require.NoError(t, err)
+		require.Equal(t, []any{"literal"}, svc.Args)
+
+		_, err = c.(*core.Container).Derive(func(*core.ContainerBuilder) error {
+			return errors.New("boom")
+		})
+		require.EqualError(t, err, "failed to modify the derived container: boom")
+	})
+	t.Run("fails to derive an unsupported container", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := di.Derive(struct{ di.Container }{})
+		require.EqualError(t, err, "container of type struct { di.Container } cannot be derived")
+	})
+}
+
+type RefreshFlags struct {
+	Version int
+}
+
+type RefreshClient struct {
+	Flags *RefreshFlags
+
+	closed   *[]string
+	closeErr error
+}
+
+func (c *RefreshClient) Close() error {
+	*c.closed = append(*c.closed, "client")
+	return c.closeErr
+}
+
+type RefreshHandler struct {
+	Client *RefreshClient
+
+	closed *[]string
+}
+
+func (h *RefreshHandler) Close() error {
+	*h.closed = append(*h.closed, "handler")
+	return nil
+}
+
+func TestRefresh(t *testing.T) {
+	build := func(t *testing.T, closed *[]string, closeErr error) (c di.Container, flagsRef, clientRef, handlerRef di.SvcReference) {
+		t.Helper()
+
+		var version int
+		c, err := di.New().
+			Services(
+				di.Svc(func() *RefreshFlags {
+					version++
+					return &RefreshFlags{Version: version}
+				}).Bind(&flagsRef),
+				di.Svc(func(flags *RefreshFlags) *RefreshClient {
+					return &RefreshClient{Flags: flags, closed: closed, closeErr: closeErr}
+				}).Bind(&clientRef),
+				di.Svc(func(client *RefreshClient) *RefreshHandler {
+					return &RefreshHandler{Client: client, closed: closed}
+				}).Bind(&handlerRef),
+				di.SvcVal(new(TestIfaceImpl)),
+			).
+			Build()
+		require.NoError(t, err)
+
+		return c, flagsRef, clientRef, handlerRef
+	}
+
+	t.Run("refreshes a service and its dependents", func(t *testing.T) {
+		t.Parallel()
+
+		var closed []string
+		c, flagsRef, clientRef, handlerRef := build(t, &closed, nil)
+
+		var events []di.RefreshEvent
+		c.OnRefresh(func(e di.RefreshEvent) { events = append(events, e) })
+
+		handler, err := di.SvcByRef[*RefreshHandler](c, handlerRef)
+		require.NoError(t, err)
+		require.Equal(t, 1, handler.Client.Flags.Version)
+		other, err := di.SvcByType[*TestIfaceImpl](c)
+		require.NoError(t, err)
+
+		require.NoError(t, di.RefreshByType[*RefreshFlags](c))
+		require.Equal(t, []string{"handler", "client"}, closed)
+		require.Len(t, events, 1)
+		require.Equal(t, []di.ID{handlerRef.SvcID(), clientRef.SvcID(), flagsRef.SvcID()}, events[0].IDs())
+
+		refreshed, err := di.SvcByRef[*RefreshHandler](c, handlerRef)
+		require.NoError(t, err)
+		require.NotSame(t, handler, refreshed)
+		require.Equal(t, 2, refreshed.Client.Flags.Version)
+		otherAfter, err := di.SvcByType[*TestIfaceImpl](c)
+		require.NoError(t, err)
+		require.Same(t, other, otherAfter)
+	})
+	t.Run("refreshes services resolved from child scopes", func(t *testing.T) {
+		t.Parallel()
+
+		var version int
+		c, err := di.New().
+			Services(
+				di.Svc(func() *RefreshFlags {
+					version++
+					return &RefreshFlags{Version: version}
+				}),
+				di.Svc(func(flags *RefreshFlags, _ string) *RefreshClient {
+					return &RefreshClient{Flags: flags}
+				}).NotShared().Children(
+					di.SvcVal("child"),
+				),
+			).
+			Build()
+		require.NoError(t, err)
+
+		client, err := di.SvcByType[*RefreshClient](c)
+		require.NoError(t, err)
+		require.Equal(t, 1, client.Flags.Version)
+
+		require.NoError(t, di.RefreshByType[*RefreshFlags](c))
+
+		client, err = di.SvcByType[*RefreshClient](c)
+		require.NoError(t, err)
+		require.Equal(t, 2, client.Flags.Version)
+	})
+	t.Run("refreshes only instantiated services", func(t *testing.T) {
+		t.Parallel()
+
+		var closed []string
+		c, _, clientRef, _ := build(t, &closed, nil)
+
+		var events []di.RefreshEvent
+		c.OnRefresh(func(e di.RefreshEvent) { events = append(events, e) })
+
+		_, err := di.SvcByRef[*RefreshClient](c, clientRef)
+		require.NoError(t, err)
+
+		require.NoError(t, di.RefreshByRef(c, clientRef))
+		require.Equal(t, []string{"client"}, closed)
+		require.Equal(t, []di.ID{clientRef.SvcID()}, events[0].IDs())
+
+		require.NoError(t, di.RefreshByRef(c, clientRef))
+		require.Empty(t, events[1].IDs())
+	})
+	t.Run("returns close errors", func(t *testing.T) {
+		t.Parallel()
+
+		var closed []string
+		c, flagsRef, _, handlerRef := build(t, &closed, errors.New("boom"))
+
+		var events []di.RefreshEvent
+		c.OnRefresh(func(e di.RefreshEvent) { events = append(events, e) })
+
+		_, err := di.SvcByRef[*RefreshHandler](c, handlerRef)
+		require.NoError(t, err)
+
+		err = di.RefreshByRef(c, flagsRef)
+		require.EqualError(t, err, "failed to close service github.com/michalkurzeja/godi/v2_test.(*RefreshClient): boom")
+		require.Equal(t, []string{"handler", "client"}, closed)
+		require.Len(t, events, 1)
+	})
+	t.Run("does not cache services instantiated during the refresh", func(t *testing.T) {
+		t.Parallel()
+
+		var closed []string
+		var version atomic.Int64
+		var blocked atomic.Bool
+		started, release := make(chan struct{}), make(chan struct{})
+
+		c, err := di.New().
+			Services(
+				di.Svc(func() *RefreshFlags { return &RefreshFlags{Version: int(version.Add(1))} }),
+				di.Svc(func(flags *RefreshFlags) *RefreshClient {
+					if blocked.CompareAndSwap(false, true) {
+						close(started)
+						<-release
+					}
+					return &RefreshClient{Flags: flags, closed: &closed}
+				}),
+			).
+			Build()
+		require.NoError(t, err)
+
+		inFlight := di.SvcByTypeAsync[*RefreshClient](t.Context(), c)
+		<-started
+		require.NoError(t, di.RefreshByType[*RefreshFlags](c))
+		close(release)
+
+		res := <-inFlight
+		require.NoError(t, res.Err)
+		require.Equal(t, 1, res.Svc.Flags.Version)
+
+		client, err := di.SvcByType[*RefreshClient](c)
+		require.NoError(t, err)
+		require.NotSame(t, res.Svc, client)
+		require.Equal(t, 2, client.Flags.Version)
+		require.Empty(t, closed)
+	})
+	t.Run("runs concurrently with resolutions", func(t *testing.T) {
+		t.Parallel()
+
+		var closed []string
+		var version atomic.Int64
+
+		c, err := di.New().
+			Services(
+				di.Svc(func() *RefreshFlags { return &RefreshFlags{Version: int(version.Add(1))} }),
+				di.Svc(func(flags *RefreshFlags) *RefreshClient {
+					return &RefreshClient{Flags: flags, closed: &closed}
+				}),
+			).
+			Build()
+		require.NoError(t, err)
+
+		results := make([]<-chan di.Result[*RefreshClient], 100)
+		for i := range results {
+			results[i] = di.SvcByTypeAsync[*RefreshClient](t.Context(), c)
+			require.NoError(t, di.RefreshByType[*RefreshFlags](c))
+		}
+		for _, ch := range results {
+			res := <-ch
+			require.NoError(t, res.Err)
+			require.NotNil(t, res.Svc.Flags)
+		}
+
+		client, err := di.SvcByType[*RefreshClient](c)
+		require.NoError(t, err)
+		flags, err := di.SvcByType[*RefreshFlags](c)
+		require.NoError(t, err)
+		require.Same(t, flags, client.Flags)
+	})
+	t.Run("fails to refresh unknown services", func(t *testing.T) {
+		t.Parallel()
+
+		var closed []string
+		c, _, _, _ := build(t, &closed, nil)
+
+		require.EqualError(t, di.RefreshByRef(c, di.SvcReference{}), "cannot refresh service: empty reference")
+		require.EqualError(t, di.RefreshByType[*TestSvc](c), "service of type github.com/michalkurzeja/godi/v2_test.(*TestSvc) not found")
+		require.EqualError(t, c.Refresh("unknown"), "cannot refresh service unknown: service not found")
+	})
+}
+
+type capturingInterceptor struct {
+	calls []core.Call
+}
+
+func (i *capturingInterceptor) Before(call core.Call) {
+	i.calls = append(i.calls, call)
+}
+
+func (i *capturingInterceptor) After(core.Call, time.Duration, error) {}
+
+func TestFrozenDefinitions(t *testing.T) {
+	t.Run("panics on modifications after build", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := new(capturingInterceptor)
+		var ref di.SvcReference
+		c, err := di.New(di.Interceptors(interceptor)).
+			Services(
+				di.SvcVal("foo"),
+				di.Svc(NewTestSvcStrArg).Bind(&ref).Labels("label"),
+			).
+			Functions(di.Func(func(*TestSvc) {})).
+			Build()
+		require.NoError(t, err)
+
+		_, err = di.SvcByRef[*TestSvc](c, ref)
+		require.NoError(t, err)
+		_, err = di.ExecByType[func(*TestSvc)](c)
+		require.NoError(t, err)
+
+		svcCall, _ := lo.Find(interceptor.calls, func(call core.Call) bool { return call.Definition.ID() == ref.SvcID() })
+		funCall, _ := lo.Find(interceptor.calls, func(call core.Call) bool { return call.Kind == core.FunctionCall })
+		svcDef := svcCall.Definition.(*core.ServiceDefinition)
+		scope := svcCall.Scope
+		funDef := funCall.Definition.(*core.FunctionDefinition)
+
+		require.True(t, svcDef.IsFrozen())
+		require.True(t, funDef.IsFrozen())
+		require.True(t, scope.IsFrozen())
+
+		const frozen = ": it is frozen, as its container is already built"
+		require.PanicsWithValue(t, "cannot modify service definition "+svcDef.String()+frozen, func() { svcDef.SetLabels("other") })
+		require.PanicsWithValue(t, "cannot modify service definition "+svcDef.String()+frozen, func() { svcDef.SetFactory(nil) })
+		require.PanicsWithValue(t, "cannot modify service definition "+svcDef.String()+frozen, func() { svcDef.AddMethodCalls() })
+		require.PanicsWithValue(t, "cannot modify function definition "+funDef.String()+frozen, func() { funDef.SetLazy(false) })
+		require.PanicsWithValue(t, "cannot modify argument slot 0"+frozen, func() {
+			_ = svcDef.Factory().Args().Slots()[0].Set(core.NewLiteralArg("bar"))
+		})
+		require.PanicsWithValue(t, "cannot modify scope root"+frozen, func() { scope.RemoveServiceDefinitions(ref.SvcID()) })
+		require.PanicsWithValue(t, "cannot modify scope root"+frozen, func() { scope.NewChild("child") })
+		require.PanicsWithValue(t, "cannot modify definition registry"+frozen, func() { scope.Services().Remove(ref.SvcID()) })
+
+		svc, err := di.SvcByRef[*TestSvc](c, ref)
+		require.NoError(t, err)
+		require.Equal(t, []any{"foo"}, svc.Args)
+	})
+	t.Run("does not expose the live bindings after build", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := new(capturingInterceptor)
+		c, err := di.New(di.Interceptors(interceptor)).
+			Services(
+				di.Svc(func() *TestIfaceImpl { return new(TestIfaceImpl) }),
+				di.Svc(NewTestSvcIfaceArg).NotShared(),
+			).
+			Bindings(di.BindType[TestIface, *TestIfaceImpl]()).
+			Build()
+		require.NoError(t, err)
+
+		_, err = di.SvcByType[*TestIfaceImpl](c)
+		require.NoError(t, err)
+		scope := interceptor.calls[0].Scope
+
+		bindings := scope.Bindings()
+		require.Equal(t, 1, bindings.Len())
+		bindings.Delete(reflect.TypeFor[TestIface]())
+
+		require.Len(t, scope.GetBindings(), 1)
+		_, err = di.SvcByType[*TestSvc](c)
+		require.NoError(t, err)
+	})
+	t.Run("exposes read-only views", func(t *testing.T) {
+		t.Parallel()
+
+		var strRef, svcRef di.SvcReference
+		c, err := di.New().
+			Services(
+				di.SvcVal("foo").Bind(&strRef),
+				di.Svc(NewTestSvcStrArg).Bind(&svcRef).Name("svc").Labels("label").Tag("tag", di.Attrs{"a": 1}).Priority(3),
+			).
+			Functions(di.Func(func(*TestSvc) {})).
+			Build()
+		require.NoError(t, err)
+
+		views := c.ServiceDefinitions()
+		require.Len(t, views, 2)
+		view := views[1]
+		require.Equal(t, svcRef.SvcID(), view.ID())
+		require.Equal(t, reflect.TypeFor[*TestSvc](), view.Type())
+		require.Equal(t, "svc", view.Name())
+		require.Equal(t, []di.Label{"label", "tag"}, view.Labels())
+		require.Equal(t, 3, view.Priority())
+		require.Equal(t, "root", view.ScopeName())
+		require.Equal(t, []di.ID{strRef.SvcID()}, view.Dependencies())
+		require.True(t, view.IsShared())
+
+		view.Labels()[0] = "changed"
+		view.Tags()[0].Attrs["a"] = 2
+		require.Equal(t, []di.Label{"label", "tag"}, view.Labels())
+		require.Equal(t, di.Attrs{"a": 1}, view.Tags()[0].Attrs)
+
+		funs := c.FunctionDefinitions()
+		require.Len(t, funs, 1)
+		require.Equal(t, []di.ID{svcRef.SvcID()}, funs[0].Dependencies())
+
+		found, ok := c.(*core.Container).ServiceDefinition(svcRef.SvcID())
+		require.True(t, ok)
+		require.Equal(t, view, found)
+	})
+}
+
+func TestTypedRef(t *testing.T) {
+	t.Run("binds and resolves typed references", func(t *testing.T) {
+		t.Parallel()
+
+		var strRef di.TypedRef[string]
+		var svcRef di.TypedRef[*TestSvc]
+		var ifaceRef di.TypedRef[TestIface]
+		c, err := di.New().
+			Services(
+				di.SvcVal("foo").Bind(&strRef),
+				di.SvcVal("bar"),
+				di.Svc(NewTestSvcStrArg, &strRef).Bind(&svcRef),
+				di.Svc(NewTestSvcIfaceArg, ifaceRef.Arg()),
+				di.SvcVal(&TestIfaceImpl{}).Bind(&ifaceRef),
+			).
+			Build()
+		require.NoError(t, err)
+
+		str, err := di.Get(c, strRef)
+		require.NoError(t, err)
+		require.Equal(t, "foo", str)
+
+		svc, err := di.Get(c, svcRef)
+		require.NoError(t, err)
+		require.Equal(t, []any{"foo"}, svc.Args)
+
+		iface, err := di.Get(c, ifaceRef)
+		require.NoError(t, err)
+		require.IsType(t, &TestIfaceImpl{}, iface)
+
+		_, err = di.Get(c, di.TypedRef[string]{})
+		require.EqualError(t, err, "service not found: empty reference")
+	})
+	t.Run("fails to bind a service of an incompatible type", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.TypedRef[int]
+		_, err := di.New().
+			Services(di.SvcVal("foo").Bind(&ref)).
+			Build()
+		require.ErrorContains(t, err, "reference of type int cannot be bound to service of type string")
+	})
+	t.Run("fails to pass a typed reference to an incompatible slot", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.TypedRef[TestIface]
+		_, err := di.New().
+			Services(
+				di.SvcVal(&TestIfaceImpl{}).Bind(&ref),
+				di.Svc(NewTestSvcStrArg, &ref),
+			).
+			Build()
+		require.ErrorContains(t, err, "failed to add factory args: argument di_test.TestIface cannot be slotted to function")
+	})
+}
+
+func TestProvide(t *testing.T) {
+	t.Run("registers services with typed factories and args", func(t *testing.T) {
+		t.Parallel()
+
+		type Pair struct {
+			Str  string
+			Svc  *TestSvc
+			Strs []string
+		}
+
+		var strRef di.TypedRef[string]
+		var svcRef, otherRef di.TypedRef[*TestSvc]
+		var pairRef di.TypedRef[Pair]
+		c, err := di.New().
+			Services(
+				di.Provide(func() (string, error) { return "foo", nil }).Bind(&strRef),
+				di.Provide1(func(s string) (*TestSvc, error) { return NewTestSvcStrArg(s), nil }, di.RefOf(&strRef)).Bind(&svcRef),
+				di.Provide1(func(s string) (*TestSvc, error) { return NewTestSvcStrArg(s), nil }, di.ValOf("bar")).Bind(&otherRef),
+				di.Provide3(
+					func(str string, svc *TestSvc, strs []string) (Pair, error) { return Pair{str, svc, strs}, nil },
+					di.Auto[string](),
+					di.RefOf(&svcRef),
+					di.ArgOf[[]string](di.SliceOf[string]()),
+				).Bind(&pairRef),
+			).
+			Build()
+		require.NoError(t, err)
+
+		other, err := di.Get(c, otherRef)
+		require.NoError(t, err)
+		require.Equal(t, []any{"bar"}, other.Args)
+
+		pair, err := di.Get(c, pairRef)
+		require.NoError(t, err)
+		svc, err := di.Get(c, svcRef)
+		require.NoError(t, err)
+		require.Same(t, svc, pair.Svc)
+		require.Equal(t, []any{"foo"}, pair.Svc.Args)
+		require.Equal(t, "foo", pair.Str)
+		require.Equal(t, []string{"foo"}, pair.Strs)
+	})
+	t.Run("fails on an arg builder of an incompatible type", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := di.New().
+			Services(di.Provide1(func(n int) (int, error) { return n, nil }, di.ArgOf[int](di.Val("foo")))).
+			Build()
+		require.ErrorContains(t, err, "failed to add factory args")
+	})
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	var shared, notShared di.SvcReference
+	var fn di.FuncReference
+
+	c, err := di.New(di.CollectStats()).
+		Services(
+			di.SvcVal("foo").Bind(&shared),
+			di.Svc(func(s string) (int, error) { return len(s), nil }).NotShared().Bind(&notShared),
+			di.Svc(func() (float64, error) { return 0, errors.New("oops") }),
+		).
+		Functions(
+			di.Func(func(int) {}).Bind(&fn),
+		).
+		Build()
+	require.NoError(t, err)
+
+	for range 3 {
+		_, err = di.ExecByRef(c, fn)
+		require.NoError(t, err)
+	}
+	_, err = di.SvcByType[float64](c)
+	require.ErrorContains(t, err, "oops")
+
+	stats := c.Stats()
+	require.Len(t, stats, 4)
+
+	require.EqualValues(t, 1, stats[shared.SvcID()].Instantiations)
+	require.EqualValues(t, 2, stats[shared.SvcID()].CacheHits)
+	require.EqualValues(t, 3, stats[notShared.SvcID()].Instantiations)
+	require.Zero(t, stats[notShared.SvcID()].CacheHits)
+	require.EqualValues(t, 3, stats[fn.FuncID()].Executions)
+	require.GreaterOrEqual(t, stats[fn.FuncID()].Duration, stats[notShared.SvcID()].Duration)
+
+	var failed di.DefinitionStats
+	for _, ds := range stats {
+		if ds.Definition.Type() == reflect.TypeFor[float64]() {
+			failed = ds
+		}
+	}
+	require.EqualValues(t, 1, failed.Instantiations)
+	require.EqualValues(t, 1, failed.Errors)
+
+	di.PublishStats("godi-test-stats", c)
+	var published map[string]map[string]any
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("godi-test-stats").String()), &published))
+	require.Equal(t, "string", published[string(shared.SvcID())]["definition"])
+	require.EqualValues(t, 2, published[string(shared.SvcID())]["cache_hits"])
+
+	t.Run("disabled by default", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := di.New().
+			Services(di.SvcVal("foo")).
+			Build()
+		require.NoError(t, err)
+
+		_, err = di.SvcByType[string](c)
+		require.NoError(t, err)
+		require.Empty(t, c.Stats())
+	})
+
+	t.Run("kept by derived containers", func(t *testing.T) {
+		t.Parallel()
+
+		var ref di.SvcReference
+		c, err := di.New(di.CollectStats()).
+			Services(di.SvcVal("foo").Bind(&ref)).
+			Build()
+		require.NoError(t, err)
+
+		builder, err := di.Derive(c)
+		require.NoError(t, err)
+		derived, err := builder.Build()
+		require.NoError(t, err)
+
+		_, err = di.SvcByRef[string](derived, ref)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, derived.Stats()[ref.SvcID()].Instantiations)
+		require.Empty(t, c.Stats())
+	})
+}
+
+type CyclicParent struct {
+	Child *CyclicChild
+}
+
+func (p *CyclicParent) SetChild(c *CyclicChild) {
+	p.Child = c
+}
+
+type CyclicChild struct {
+	Parent *CyclicParent
+}
+
+type AsyncPool struct {
+	Conns int
+}
+
+type AsyncConn struct {
+	ready   bool
+	release chan struct{}
+	entered chan struct{}
+	err     error
+}
+
+func (c *AsyncConn) Init() error {
+	close(c.entered)
+	<-c.release
+	c.ready = true
+	return c.err
+}
+
+func TestSvcByTypeAsync(t *testing.T) {
+	t.Run("concurrent resolutions share a single instantiation", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		release := make(chan struct{})
+		c, err := di.New().
+			Services(
+				di.Svc(func() *AsyncPool {
+					calls.Add(1)
+					<-release
+					return &AsyncPool{Conns: 10}
+				}),
+			).
+			Build()
+		require.NoError(t, err)
+
+		results := make([]<-chan di.Result[*AsyncPool], 10)
+		for i := range results {
+			results[i] = di.SvcByTypeAsync[*AsyncPool](t.Context(), c)
+		}
+		require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+		close(release)
+
+		var pools []*AsyncPool
+		for _, ch := range results {
+			res, ok := <-ch
+			require.True(t, ok)
+			require.NoError(t, res.Err)
+			pools = append(pools, res.Svc)
+			_, ok = <-ch
+			require.False(t, ok, "channel should be closed after the result")
+		}
+		for _, pool := range pools {
+			require.Same(t, pools[0], pool)
+		}
+		require.EqualValues(t, 1, calls.Load())
+
+		pool, err := di.SvcByType[*AsyncPool](c)
+		require.NoError(t, err)
+		require.Same(t, pools[0], pool)
+	})
+	t.Run("services are not returned before their method calls complete", func(t *testing.T) {
+		t.Parallel()
+
+		conn := &AsyncConn{release: make(chan struct{}), entered: make(chan struct{})}
+		c, err := di.New().
+			Services(
+				di.Svc(func() *AsyncConn { return conn }).MethodCall((*AsyncConn).Init),
+			).
+			Build()
+		require.NoError(t, err)
+
+		first := di.SvcByTypeAsync[*AsyncConn](t.Context(), c)
+		<-conn.entered
+		second := di.SvcByTypeAsync[*AsyncConn](t.Context(), c)
+		select {
+		case res := <-second:
+			t.Fatalf("resolved a half-built service: %+v", res)
+		case <-time.After(10 * time.Millisecond):
+		}
+		close(conn.release)
+
+		for _, ch := range []<-chan di.Result[*AsyncConn]{first, second} {
+			res := <-ch
+			require.NoError(t, res.Err)
+			require.True(t, res.Svc.ready)
+		}
+	})
+	t.Run("returns the error of the context, leaving the resolution in progress", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		release := make(chan struct{})
+		c, err := di.New().
+			Services(
+				di.Svc(func() *AsyncPool {
+					calls.Add(1)
+					<-release
+					return &AsyncPool{}
+				}),
+			).
+			Build()
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		ch := di.SvcByTypeAsync[*AsyncPool](ctx, c)
+		require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+		cancel()
+
+		res := <-ch
+		require.ErrorIs(t, res.Err, context.Canceled)
+		require.Nil(t, res.Svc)
+
+		close(release)
+		_, err = di.SvcByType[*AsyncPool](c)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, calls.Load())
+
+		res = <-di.SvcByTypeAsync[*AsyncPool](ctx, c)
+		require.ErrorIs(t, res.Err, context.Canceled, "a done context should fail the resolution upfront")
+	})
+	t.Run("returns the error of the resolution", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := di.New().Build()
+		require.NoError(t, err)
+
+		res := <-di.SvcByTypeAsync[*AsyncPool](t.Context(), c)
+		require.ErrorContains(t, res.Err, "AsyncPool) not found")
+	})
+}
+
+func TestErrorPolicy(t *testing.T) {
+	// newPool returns a factory that fails the given number of times before it succeeds.
+	newPool := func(calls *atomic.Int32, failures int32) func() (*AsyncPool, error) {
+		return func() (*AsyncPool, error) {
+			if calls.Add(1) <= failures {
+				return nil, fmt.Errorf("connection refused (%d)", calls.Load())
+			}
+			return &AsyncPool{}, nil
+		}
+	}
+
+	t.Run("failed instantiations are not retried nor cached by default", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		c, err := di.New().Services(di.Svc(newPool(&calls, 1))).Build()
+		require.NoError(t, err)
+
+		_, err = di.SvcByType[*AsyncPool](c)
+		require.ErrorContains(t, err, "connection refused (1)")
+		require.NotContains(t, err.Error(), "gave up")
+		_, err = di.SvcByType[*AsyncPool](c)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, calls.Load())
+	})
+	t.Run("failed instantiations are retried", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		c, err := di.New().Services(di.Svc(newPool(&calls, 2)).RetryOnError(2, time.Millisecond)).Build()
+		require.NoError(t, err)
+
+		start := time.Now()
+		_, err = di.SvcByType[*AsyncPool](c)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, calls.Load())
+		require.GreaterOrEqual(t, time.Since(start), 3*time.Millisecond, "backoff should double with each retry")
+	})
+	t.Run("retries give up after the given number of attempts", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		c, err := di.New().Services(di.Svc(newPool(&calls, 3)).RetryOnError(1, 0).NotShared()).Build()
+		require.NoError(t, err)
+
+		_, err = di.SvcByType[*AsyncPool](c)
+		require.ErrorContains(t, err, "AsyncPool): gave up after 2 attempts: ")
+		require.ErrorContains(t, err, "connection refused (2)")
+		require.EqualValues(t, 2, calls.Load())
+	})
+	t.Run("errors of shared services are cached until refreshed", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		var ref di.SvcReference
+		c, err := di.New().Services(di.Svc(newPool(&calls, 2)).Bind(&ref).RetryOnError(1, 0).CacheErrors()).Build()
+		require.NoError(t, err)
+
+		_, err1 := di.SvcByRef[*AsyncPool](c, ref)
+		require.ErrorContains(t, err1, "connection refused (2)")
+		res := <-di.SvcByTypeAsync[*AsyncPool](t.Context(), c)
+		require.ErrorIs(t, res.Err, err1, "the cached error should be returned")
+		require.EqualValues(t, 2, calls.Load())
+
+		require.NoError(t, di.RefreshByRef(c, ref))
+		_, err = di.SvcByRef[*AsyncPool](c, ref)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, calls.Load())
+	})
+	t.Run("errors of method calls are cached, without caching the instance", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		c, err := di.New().
+			Services(
+				di.Svc(func() *AsyncConn {
+					calls.Add(1)
+					release := make(chan struct{})
+					close(release)
+					return &AsyncConn{release: release, entered: make(chan struct{}), err: errors.New("handshake failed")}
+				}).MethodCall((*AsyncConn).Init).CacheErrors(),
+			).
+			Build()
+		require.NoError(t, err)
+
+		_, err1 := di.SvcByType[*AsyncConn](c)
+		require.ErrorContains(t, err1, "handshake failed")
+		res := <-di.SvcByTypeAsync[*AsyncConn](t.Context(), c)
+		require.EqualError(t, res.Err, err1.Error())
+		require.Nil(t, res.Svc)
+		require.EqualValues(t, 1, calls.Load())
+	})
+	t.Run("error policy is described", func(t *testing.T) {
+		t.Parallel()
+
+		b := di.New().Services(di.Svc(newPool(new(atomic.Int32), 0)).RetryOnError(3, time.Second).CacheErrors())
+		c, err := b.Build()
+		require.NoError(t, err)
+
+		defs := c.ServiceDefinitions()
+		require.Len(t, defs, 1)
+		require.Equal(t, core.ErrorPolicy{Retries: 3, Backoff: time.Second, CacheErrors: true}, defs[0].ErrorPolicy())
+
+		var buf strings.Builder
+		c.Print(&buf)
+		require.Contains(t, buf.String(), "On error:\tretries: 3, backoff: 1s, cache errors\n")
+	})
+}
+
+func BenchmarkResolutionPlans(b *testing.B) {
+	modes := []struct {
+		name string
+		opts []di.BuilderOption
+	}{
+		{name: "with resolution plans"},
+		{name: "without resolution plans", opts: []di.BuilderOption{di.SkipResolutionPlanning()}},
+	}
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			builder := di.New(mode.opts...).
+				Services(
+					di.Svc(func(i TestIface, strs []string, byKey map[string]string, n int) *TestSvc {
+						return &TestSvc{Args: []any{i, strs, byKey, n}}
+					}, di.SliceOf[string]("str"), di.MapOf[string, string](nil, "str"), di.Named[int]("n")).NotShared(),
+					di.SvcVal(&TestIfaceImpl{}),
+					di.SvcVal(42).Name("n"),
+				).
+				Functions(
+					di.Func(func(svc *TestSvc, i TestIface) {}),
+				).
+				Bindings(
+					di.BindType[TestIface, *TestIfaceImpl](),
+				)
+			for i := range 10 {
+				builder.Services(di.SvcVal(strconv.Itoa(i)).Labels("str").Key(strconv.Itoa(i)))
+			}
+			c, err := builder.Build()
+			require.NoError(b, err)
+
+			b.Run("not shared service", func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					_, err := di.SvcByType[*TestSvc](c)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run("function", func(b *testing.B) {
+				fnType := reflect.TypeFor[func(*TestSvc, TestIface)]()
+				b.ReportAllocs()
+				for b.Loop() {
+					_, err := c.ExecuteFunctionsByType(fnType)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
 type Refs struct {
 	Svc  SvcRefs
 	Func FuncRefs
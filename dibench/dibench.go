@@ -0,0 +1,148 @@
+// Package dibench builds synthetic containers for the benchmarks of the container hot paths.
+//
+// The graph has Config.Depth levels of Config.Width services each. The services of the first level (leaves)
+// have no dependencies, and each service of a higher level depends on all services of the level below,
+// injected as a labelled slice of an interface. Each service above the leaves has a child scope with its own
+// service, and depends on an interface bound to the logger. On top of the graph, there is a shared Entry,
+// a not shared Request (created per resolution) and a Handle function.
+//
+// The benchmarks and the allocation budget are defined in the tests of the package,
+// see README for the budget.
+package dibench
+
+import (
+	"errors"
+	"fmt"
+
+	godi "github.com/michalkurzeja/godi/v2"
+)
+
+// Config configures the shape of the synthetic graph.
+type Config struct {
+	// Width is the number of services of each level.
+	Width int
+	// Depth is the number of levels.
+	Depth int
+}
+
+// String returns a name of the config, for the sub-benchmarks.
+func (c Config) String() string {
+	return fmt.Sprintf("width=%d/depth=%d", c.Width, c.Depth)
+}
+
+// NewBuilder returns a builder of the synthetic graph.
+func NewBuilder(conf Config, opts ...godi.BuilderOption) *godi.Builder {
+	b := godi.New(opts...).
+		Services(
+			godi.Svc(NewStdLogger),
+			godi.Svc(NewEntry, godi.SliceOf[Component](levelLabel(conf.Depth-1))).Name("entry"),
+			godi.Svc(NewRequest, godi.SliceOf[Component](levelLabel(conf.Depth-1))).NotShared(),
+		).
+		Functions(
+			godi.Func(Handle),
+		).
+		Bindings(
+			godi.BindType[Logger, *StdLogger](),
+		)
+
+	for i := range conf.Width {
+		b.Services(godi.Svc(NewLeaf, i).Labels(levelLabel(0)))
+	}
+	for level := 1; level < conf.Depth; level++ {
+		for i := range conf.Width {
+			b.Services(
+				godi.Svc(NewNode, godi.SliceOf[Component](levelLabel(level-1))).
+					Labels(levelLabel(level), godi.Label(fmt.Sprintf("node-%d-%d", level, i))). // Child scopes are named after their parents.
+					Children(godi.Svc(NewLocal, fmt.Sprintf("local-%d-%d", level, i))),
+			)
+		}
+	}
+
+	return b
+}
+
+func levelLabel(level int) godi.Label {
+	return godi.Label(fmt.Sprintf("level-%d", level))
+}
+
+// Component is implemented by all services of the graph levels.
+type Component interface {
+	Size() int
+}
+
+type Logger interface {
+	Log(msg string)
+}
+
+type StdLogger struct{}
+
+func NewStdLogger() *StdLogger {
+	return &StdLogger{}
+}
+
+func (l *StdLogger) Log(string) {}
+
+type Leaf struct {
+	i int
+}
+
+func NewLeaf(i int) *Leaf {
+	return &Leaf{i: i}
+}
+
+func (l *Leaf) Size() int {
+	return 1
+}
+
+type Local struct {
+	name string
+}
+
+func NewLocal(name string) *Local {
+	return &Local{name: name}
+}
+
+type Node struct {
+	local  *Local
+	logger Logger
+	deps   []Component
+}
+
+func NewNode(local *Local, logger Logger, deps []Component) *Node {
+	return &Node{local: local, logger: logger, deps: deps}
+}
+
+// Size returns the number of services the node depends on, including itself (with repetitions).
+func (n *Node) Size() int {
+	size := 1
+	for _, dep := range n.deps {
+		size += dep.Size()
+	}
+	return size
+}
+
+type Entry struct {
+	top []Component
+}
+
+func NewEntry(top []Component) *Entry {
+	return &Entry{top: top}
+}
+
+type Request struct {
+	entry  *Entry
+	logger Logger
+	top    []Component
+}
+
+func NewRequest(entry *Entry, logger Logger, top []Component) *Request {
+	return &Request{entry: entry, logger: logger, top: top}
+}
+
+func Handle(req *Request, logger Logger) error {
+	logger.Log("handled")
+	if req.entry == nil {
+		return errors.New("invalid request")
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package dibench_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	godi "github.com/michalkurzeja/godi/v2"
+	"github.com/michalkurzeja/godi/v2/dibench"
+)
+
+var configs = []dibench.Config{
+	{Width: 4, Depth: 2},
+	{Width: 16, Depth: 4},
+	{Width: 64, Depth: 8},
+}
+
+var modes = []struct {
+	name string
+	opts []godi.BuilderOption
+}{
+	{name: "plans"},
+	{name: "no-plans", opts: []godi.BuilderOption{godi.SkipResolutionPlanning()}},
+}
+
+func BenchmarkBuild(b *testing.B) {
+	for _, conf := range configs {
+		b.Run(conf.String(), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				_, err := dibench.NewBuilder(conf).Build()
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSharedFetch(b *testing.B) {
+	for _, conf := range configs {
+		b.Run(conf.String(), func(b *testing.B) {
+			c := build(b, conf)
+			b.ReportAllocs()
+			for b.Loop() {
+				_, err := godi.SvcByType[*dibench.Entry](c)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkNotSharedInstantiation(b *testing.B) {
+	for _, mode := range modes {
+		for _, conf := range configs {
+			b.Run(mode.name+"/"+conf.String(), func(b *testing.B) {
+				c := build(b, conf, mode.opts...)
+				b.ReportAllocs()
+				for b.Loop() {
+					_, err := godi.SvcByType[*dibench.Request](c)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkFunctionExecution(b *testing.B) {
+	for _, mode := range modes {
+		for _, conf := range configs {
+			b.Run(mode.name+"/"+conf.String(), func(b *testing.B) {
+				c := build(b, conf, mode.opts...)
+				b.ReportAllocs()
+				for b.Loop() {
+					_, err := godi.ExecByType[func(*dibench.Request, dibench.Logger) error](c)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestAllocationBudget enforces the allocation budget of the hot paths (see README).
+// Raising a budget requires a justification in the pull request.
+func TestAllocationBudget(t *testing.T) {
+	conf := dibench.Config{Width: 8, Depth: 4}
+
+	tests := []struct {
+		name   string
+		budget float64
+		run    func(t *testing.T) func()
+	}{
+		{
+			name:   "build",
+			budget: 5500,
+			run: func(t *testing.T) func() {
+				return func() {
+					_, err := dibench.NewBuilder(conf).Build()
+					require.NoError(t, err)
+				}
+			},
+		},
+		{
+			name:   "shared fetch",
+			budget: 3,
+			run: func(t *testing.T) func() {
+				c := build(t, conf)
+				return func() {
+					_, err := godi.SvcByType[*dibench.Entry](c)
+					require.NoError(t, err)
+				}
+			},
+		},
+		{
+			name:   "not shared instantiation",
+			budget: 20,
+			run: func(t *testing.T) func() {
+				c := build(t, conf)
+				return func() {
+					_, err := godi.SvcByType[*dibench.Request](c)
+					require.NoError(t, err)
+				}
+			},
+		},
+		{
+			name:   "function execution",
+			budget: 22,
+			run: func(t *testing.T) func() {
+				c := build(t, conf)
+				return func() {
+					_, err := godi.ExecByType[func(*dibench.Request, dibench.Logger) error](c)
+					require.NoError(t, err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocs := testing.AllocsPerRun(20, tt.run(t))
+			t.Logf("%s: %.0f allocs/op (budget: %.0f)", tt.name, allocs, tt.budget)
+			require.LessOrEqualf(t, allocs, tt.budget, "%s exceeds its allocation budget", tt.name)
+		})
+	}
+}
+
+func TestGraph(t *testing.T) {
+	conf := dibench.Config{Width: 3, Depth: 3}
+	c := build(t, conf)
+
+	entry, err := godi.SvcByType[*dibench.Entry](c)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+
+	nodes, err := godi.SvcsByType[*dibench.Node](c)
+	require.NoError(t, err)
+	require.Len(t, nodes, conf.Width*(conf.Depth-1))
+	for _, node := range nodes {
+		require.Contains(t, []int{1 + conf.Width, 1 + conf.Width*(1+conf.Width)}, node.Size())
+	}
+
+	req1, err := godi.SvcByType[*dibench.Request](c)
+	require.NoError(t, err)
+	req2, err := godi.SvcByType[*dibench.Request](c)
+	require.NoError(t, err)
+	require.NotSame(t, req1, req2)
+
+	res, err := godi.ExecByType[func(*dibench.Request, dibench.Logger) error](c)
+	require.NoError(t, err)
+	require.Equal(t, []any{nil}, res)
+}
+
+// build builds the container and instantiates the shared services, so that the benchmarks don't measure their first (lazy) instantiation.
+func build(tb testing.TB, conf dibench.Config, opts ...godi.BuilderOption) godi.Container {
+	tb.Helper()
+	c, err := dibench.NewBuilder(conf, opts...).Build()
+	require.NoError(tb, err)
+	_, err = godi.SvcByType[*dibench.Entry](c)
+	require.NoError(tb, err)
+	return c
+}
@@ -0,0 +1,52 @@
+// Package digen generates reflection-free Go code from godi container definitions, see cmd/godi-gen.
+package digen
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+
+	godi "github.com/michalkurzeja/godi/v2"
+	"github.com/michalkurzeja/godi/v2/di"
+	"github.com/michalkurzeja/godi/v2/internal/errorsx"
+)
+
+// Config configures the generated code, see di.GenerateConfig.
+type Config = di.GenerateConfig
+
+// errGenerated stops the build once the code is generated, so that no services are instantiated.
+var errGenerated = errors.New("code generated")
+
+// Generate compiles the container and writes Go code that constructs its services with direct calls
+// (see di.Generate) to w. Compilation doubles as validation: the code is only generated for valid definitions.
+// A clone of the builder is compiled up to the finalization stage, so no services are instantiated
+// (eager services included), and the builder itself is left unchanged.
+func Generate(builder *godi.Builder, w io.Writer, conf Config) error {
+	builder, err := builder.Clone()
+	if err != nil {
+		return errorsx.Wrap(err, "invalid container definitions")
+	}
+
+	var buf bytes.Buffer
+	var genErr error
+	// The generation runs last in its stage, so that it includes the changes of other pre-finalization passes.
+	builder.CompilerPasses(di.NewCompilerPass("code generation", di.PreFinalization, di.CompilerOpFunc(func(builder *di.ContainerBuilder) error {
+		genErr = di.Generate(builder.Scopes(), &buf, conf)
+		return errGenerated
+	})).WithPriority(math.MinInt))
+
+	_, err = builder.Build()
+	if !errors.Is(err, errGenerated) {
+		if err == nil {
+			return errors.New("code was not generated")
+		}
+		return errorsx.Wrap(err, "invalid container definitions")
+	}
+	if genErr != nil {
+		return errorsx.Wrap(genErr, "failed to generate code")
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
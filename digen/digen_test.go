@@ -0,0 +1,131 @@
+package digen_test
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	godi "github.com/michalkurzeja/godi/v2"
+	"github.com/michalkurzeja/godi/v2/digen"
+	"github.com/michalkurzeja/godi/v2/digen/internal/testapp"
+)
+
+var testappConf = digen.Config{
+	PkgName:         "testapp",
+	PkgPath:         "github.com/michalkurzeja/godi/v2/digen/internal/testapp",
+	TypeName:        "Container",
+	Source:          "Wiring",
+	BuildConstraint: "!godigen",
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("generates the checked-in code", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		require.NoError(t, digen.Generate(testapp.NewWiring(), &buf, testappConf))
+
+		want, err := os.ReadFile("internal/testapp/wiring_gen.go")
+		require.NoError(t, err)
+		require.Equal(t, string(want), buf.String(), "the generated code is outdated, run go generate ./...")
+	})
+	t.Run("fails on invalid definitions", func(t *testing.T) {
+		t.Parallel()
+
+		builder := godi.New().Services(godi.Svc(testapp.NewRepo))
+		err := digen.Generate(builder, new(bytes.Buffer), testappConf)
+		require.ErrorContains(t, err, "invalid container definitions")
+		require.ErrorContains(t, err, "no services found for type github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Config)")
+	})
+	t.Run("fails on definitions that cannot be generated", func(t *testing.T) {
+		t.Parallel()
+
+		builder := godi.New().Services(
+			godi.Svc(func() *testapp.Config { return &testapp.Config{} }),
+			godi.Svc(testapp.NewMiddleware, "auth"),
+			godi.Svc(testapp.NewHandler, "users", &testapp.Repo{}),
+		)
+		err := digen.Generate(builder, new(bytes.Buffer), testappConf)
+		require.ErrorContains(t, err, "cannot generate service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Config): invalid factory: "+
+			"function github.com/michalkurzeja/godi/v2/digen_test.TestGenerate.func3.1 is not a top-level function")
+		require.ErrorContains(t, err, "cannot generate service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Handler): invalid factory arguments: "+
+			"argument 1: literal of type github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Repo) cannot be generated")
+		require.NotContains(t, err.Error(), "Middleware")
+	})
+	t.Run("leaves the builder intact", func(t *testing.T) {
+		t.Parallel()
+
+		builder := testapp.NewWiring()
+		require.NoError(t, digen.Generate(builder, new(bytes.Buffer), testappConf))
+
+		c, err := builder.Build()
+		require.NoError(t, err)
+		_, err = godi.SvcByType[*testapp.Router](c)
+		require.NoError(t, err)
+	})
+}
+
+func TestGeneratedContainer(t *testing.T) {
+	configs, migrations := testapp.EagerConfigs(), testapp.Migrations()
+	gc, err := testapp.NewContainer()
+	require.NoError(t, err)
+	require.Equal(t, configs+1, testapp.EagerConfigs(), "eager services should be initialised")
+	require.Equal(t, migrations+1, testapp.Migrations(), "eager functions should be executed")
+
+	c, err := testapp.NewWiring().Build()
+	require.NoError(t, err)
+
+	want, err := godi.SvcByType[*testapp.Server](c)
+	require.NoError(t, err)
+	got, err := gc.Server()
+	require.NoError(t, err)
+	require.Equal(t, "app: router users, orders (by name: 2), app: repo (retries: 3, timeout: 1s), middleware auth", got.Describe())
+	require.Equal(t, want.Describe(), got.Describe())
+
+	repo1, err := gc.Repo()
+	require.NoError(t, err)
+	repo2, err := gc.Repo()
+	require.NoError(t, err)
+	require.Same(t, repo1, repo2, "shared services should be instantiated once")
+
+	id1, err := gc.RequestID()
+	require.NoError(t, err)
+	id2, err := gc.RequestID()
+	require.NoError(t, err)
+	require.NotEqual(t, id1, id2, "services that are not shared should be instantiated on each call")
+
+	migrations = testapp.Migrations()
+	res, err := gc.Migrate()
+	require.NoError(t, err)
+	require.NoError(t, res)
+	require.Equal(t, migrations+1, testapp.Migrations())
+}
+
+func TestGeneratedContainer_Concurrent(t *testing.T) {
+	gc, err := testapp.NewContainer()
+	require.NoError(t, err)
+
+	const n = 8
+	servers := make([]*testapp.Server, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		go func() {
+			defer wg.Done()
+			server, err := gc.Server()
+			assert.NoError(t, err)
+			servers[i] = server
+			_, err = gc.Migrate()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	for _, server := range servers {
+		require.Same(t, servers[0], server, "shared services should be instantiated once")
+	}
+}
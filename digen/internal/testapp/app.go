@@ -0,0 +1,164 @@
+// Package testapp is an application wired with godi, whose container is generated by godi-gen.
+package testapp
+
+//go:generate go run github.com/michalkurzeja/godi/v2/cmd/godi-gen -o wiring_gen.go
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	godi "github.com/michalkurzeja/godi/v2"
+)
+
+// Wiring defines the services of the application.
+var Wiring = NewWiring()
+
+// NewWiring returns a new builder with the definitions of the services of the application.
+func NewWiring() *godi.Builder {
+	return godi.New().
+		Services(
+			godi.Svc(NewConfig, "app", 3, time.Second).Eager(),
+			godi.Svc(newStdLogger).Name("logger"),
+			godi.Svc(NewRepo).MethodCall((*Repo).SetLogger),
+			godi.Svc(NewRequestID).NotShared(),
+			godi.Svc(NewHandler, "users").Labels("handler").Key("users").Priority(1),
+			godi.Svc(NewHandler, "orders").Labels("handler").Key("orders").Priority(2),
+			godi.Svc(NewRouter, godi.SliceOf[*Handler]("handler").Reverse(), godi.MapOf[string, *Handler](nil, "handler")),
+			godi.Svc(NewServer).Children(
+				godi.Svc(NewMiddleware, "auth"),
+			),
+		).
+		Functions(
+			godi.Func(Migrate).Eager(),
+		).
+		Bindings(
+			godi.BindType[Logger, *stdLogger](),
+		)
+}
+
+var eagerConfigs, migrations atomic.Int64
+
+// EagerConfigs returns the number of configs created so far.
+func EagerConfigs() int64 {
+	return eagerConfigs.Load()
+}
+
+// Migrations returns the number of migrations executed so far.
+func Migrations() int64 {
+	return migrations.Load()
+}
+
+type Config struct {
+	Name    string
+	Retries int
+	Timeout time.Duration
+}
+
+func NewConfig(name string, retries int, timeout time.Duration) *Config {
+	eagerConfigs.Add(1)
+	return &Config{Name: name, Retries: retries, Timeout: timeout}
+}
+
+type Logger interface {
+	Log(msg string) string
+}
+
+type stdLogger struct {
+	prefix string
+}
+
+func newStdLogger(cfg *Config) *stdLogger {
+	return &stdLogger{prefix: cfg.Name}
+}
+
+func (l *stdLogger) Log(msg string) string {
+	return l.prefix + ": " + msg
+}
+
+type Repo struct {
+	cfg    *Config
+	logger Logger
+}
+
+func NewRepo(cfg *Config) (*Repo, error) {
+	if cfg.Retries < 0 {
+		return nil, errors.New("invalid retries")
+	}
+	return &Repo{cfg: cfg}, nil
+}
+
+func (r *Repo) SetLogger(logger Logger) {
+	r.logger = logger
+}
+
+func (r *Repo) Describe() string {
+	return r.logger.Log(fmt.Sprintf("repo (retries: %d, timeout: %s)", r.cfg.Retries, r.cfg.Timeout))
+}
+
+var requestIDs atomic.Int64
+
+type RequestID int64
+
+func NewRequestID() RequestID {
+	return RequestID(requestIDs.Add(1))
+}
+
+type Handler struct {
+	Name string
+	repo *Repo
+}
+
+func NewHandler(name string, repo *Repo) *Handler {
+	return &Handler{Name: name, repo: repo}
+}
+
+type Router struct {
+	handlers []*Handler
+	byName   map[string]*Handler
+}
+
+func NewRouter(handlers []*Handler, byName map[string]*Handler) *Router {
+	return &Router{handlers: handlers, byName: byName}
+}
+
+func (r *Router) Describe() string {
+	names := make([]string, len(r.handlers))
+	for i, h := range r.handlers {
+		names[i] = h.Name
+	}
+	return fmt.Sprintf("router %s (by name: %d)", strings.Join(names, ", "), len(r.byName))
+}
+
+type Middleware struct {
+	Name string
+}
+
+func NewMiddleware(name string) *Middleware {
+	return &Middleware{Name: name}
+}
+
+type Server struct {
+	router     *Router
+	middleware *Middleware
+	logger     Logger
+	repo       *Repo
+}
+
+func NewServer(router *Router, middleware *Middleware, logger Logger, repo *Repo) *Server {
+	return &Server{router: router, middleware: middleware, logger: logger, repo: repo}
+}
+
+func (s *Server) Describe() string {
+	return s.logger.Log(fmt.Sprintf("%s, %s, middleware %s", s.router.Describe(), s.repo.Describe(), s.middleware.Name))
+}
+
+func Migrate(repo *Repo) error {
+	migrations.Add(1)
+	if repo == nil {
+		return errors.New("no repo")
+	}
+	return nil
+}
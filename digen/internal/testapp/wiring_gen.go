@@ -0,0 +1,250 @@
+//go:build !godigen
+
+// Code generated by godi-gen. DO NOT EDIT.
+
+package testapp
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Container constructs the services defined in Wiring with direct calls.
+type Container struct {
+	mu sync.Mutex // Guards the instances. The unexported getters must be called with it held.
+
+	instance0   *Config
+	instance0OK bool
+	instance1   *stdLogger
+	instance1OK bool
+	instance2   *Repo
+	instance2OK bool
+	instance4   *Handler
+	instance4OK bool
+	instance5   *Handler
+	instance5OK bool
+	instance6   *Router
+	instance6OK bool
+	instance7   *Server
+	instance7OK bool
+	instance8   *Middleware
+	instance8OK bool
+}
+
+// NewContainer creates the container and initialises its eager services and functions.
+func NewContainer() (*Container, error) {
+	c := &Container{}
+	if _, err := c.svc0(); err != nil {
+		return nil, fmt.Errorf("failed to initialise eager service %s: %w", "github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Config)", err)
+	}
+	if _, err := c.fn0(); err != nil {
+		return nil, fmt.Errorf("failed to execute eager function %s: %w", "github.com/michalkurzeja/godi/v2/digen/internal/testapp.Migrate", err)
+	}
+	return c, nil
+}
+
+// Config returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Config).
+func (c *Container) Config() (*Config, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.svc0()
+}
+
+// Logger returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*stdLogger).
+func (c *Container) Logger() (*stdLogger, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.svc1()
+}
+
+// Repo returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Repo).
+func (c *Container) Repo() (*Repo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.svc2()
+}
+
+// RequestID returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.RequestID.
+func (c *Container) RequestID() (RequestID, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.svc3()
+}
+
+// Router returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Router).
+func (c *Container) Router() (*Router, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.svc6()
+}
+
+// Server returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Server).
+func (c *Container) Server() (*Server, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.svc7()
+}
+
+// Migrate executes the function github.com/michalkurzeja/godi/v2/digen/internal/testapp.Migrate.
+func (c *Container) Migrate() (error, error) {
+	return c.fn0()
+}
+
+// svc0 returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Config) (scope: root).
+func (c *Container) svc0() (*Config, error) {
+	if c.instance0OK {
+		return c.instance0, nil
+	}
+	svc := NewConfig("app", 3, time.Duration(1000000000))
+	c.instance0, c.instance0OK = svc, true
+	return svc, nil
+}
+
+// svc1 returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*stdLogger) (scope: root).
+func (c *Container) svc1() (*stdLogger, error) {
+	if c.instance1OK {
+		return c.instance1, nil
+	}
+	v1, err := c.svc0()
+	if err != nil {
+		return *new(*stdLogger), err
+	}
+	svc := newStdLogger(v1)
+	c.instance1, c.instance1OK = svc, true
+	return svc, nil
+}
+
+// svc2 returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Repo) (scope: root).
+func (c *Container) svc2() (*Repo, error) {
+	if c.instance2OK {
+		return c.instance2, nil
+	}
+	v1, err := c.svc0()
+	if err != nil {
+		return *new(*Repo), err
+	}
+	svc, err := NewRepo(v1)
+	if err != nil {
+		return *new(*Repo), fmt.Errorf("failed to execute factory for service %s: %w", "github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Repo)", err)
+	}
+	c.instance2, c.instance2OK = svc, true
+	v2, err := c.svc1()
+	if err != nil {
+		return *new(*Repo), err
+	}
+	svc.SetLogger(v2)
+	return svc, nil
+}
+
+// svc3 returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.RequestID (scope: root).
+func (c *Container) svc3() (RequestID, error) {
+	svc := NewRequestID()
+	return svc, nil
+}
+
+// svc4 returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Handler) (handler) (scope: root).
+func (c *Container) svc4() (*Handler, error) {
+	if c.instance4OK {
+		return c.instance4, nil
+	}
+	v1, err := c.svc2()
+	if err != nil {
+		return *new(*Handler), err
+	}
+	svc := NewHandler("users", v1)
+	c.instance4, c.instance4OK = svc, true
+	return svc, nil
+}
+
+// svc5 returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Handler) (handler) (scope: root).
+func (c *Container) svc5() (*Handler, error) {
+	if c.instance5OK {
+		return c.instance5, nil
+	}
+	v1, err := c.svc2()
+	if err != nil {
+		return *new(*Handler), err
+	}
+	svc := NewHandler("orders", v1)
+	c.instance5, c.instance5OK = svc, true
+	return svc, nil
+}
+
+// svc6 returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Router) (scope: root).
+func (c *Container) svc6() (*Router, error) {
+	if c.instance6OK {
+		return c.instance6, nil
+	}
+	v1, err := c.svc5()
+	if err != nil {
+		return *new(*Router), err
+	}
+	v2, err := c.svc4()
+	if err != nil {
+		return *new(*Router), err
+	}
+	v3 := slices.Clone([]*Handler{v1, v2})
+	slices.Reverse(v3)
+	v4, err := c.svc5()
+	if err != nil {
+		return *new(*Router), err
+	}
+	v5, err := c.svc4()
+	if err != nil {
+		return *new(*Router), err
+	}
+	svc := NewRouter(v3, map[string]*Handler{"orders": v4, "users": v5})
+	c.instance6, c.instance6OK = svc, true
+	return svc, nil
+}
+
+// svc7 returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Server) (scope: root).
+func (c *Container) svc7() (*Server, error) {
+	if c.instance7OK {
+		return c.instance7, nil
+	}
+	v1, err := c.svc6()
+	if err != nil {
+		return *new(*Server), err
+	}
+	v2, err := c.svc8()
+	if err != nil {
+		return *new(*Server), err
+	}
+	v3, err := c.svc1()
+	if err != nil {
+		return *new(*Server), err
+	}
+	v4, err := c.svc2()
+	if err != nil {
+		return *new(*Server), err
+	}
+	svc := NewServer(v1, v2, v3, v4)
+	c.instance7, c.instance7OK = svc, true
+	return svc, nil
+}
+
+// svc8 returns the service github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Middleware) (scope: root/github.com/michalkurzeja/godi/v2/digen/internal/testapp.(*Server)).
+func (c *Container) svc8() (*Middleware, error) {
+	if c.instance8OK {
+		return c.instance8, nil
+	}
+	svc := NewMiddleware("auth")
+	c.instance8, c.instance8OK = svc, true
+	return svc, nil
+}
+
+// fn0 executes the function github.com/michalkurzeja/godi/v2/digen/internal/testapp.Migrate (scope: root).
+func (c *Container) fn0() (r0 error, err error) {
+	c.mu.Lock()
+	v1, err := c.svc2()
+	if err != nil {
+		c.mu.Unlock()
+		return r0, err
+	}
+	c.mu.Unlock()
+	r0 = Migrate(v1)
+	return r0, nil
+}
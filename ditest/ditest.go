@@ -0,0 +1,168 @@
+// Package ditest contains helpers for testing services managed by godi.
+package ditest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	godi "github.com/michalkurzeja/godi/v2"
+	"github.com/michalkurzeja/godi/v2/di"
+	"github.com/michalkurzeja/godi/v2/internal/errorsx"
+	"github.com/michalkurzeja/godi/v2/internal/util"
+)
+
+// MockFactory creates a mock of the given interface type.
+// It should return nil if it's unable to mock the interface.
+type MockFactory func(t testing.TB, iface reflect.Type) any
+
+type config struct {
+	mockFactories []MockFactory
+}
+
+type Option func(*config)
+
+// AutoMock makes BuildFor fill all interface-typed dependencies that have
+// no registered implementation with mocks created by the given factory.
+// The mocks are registered as services of their own type, so they can be
+// retrieved from the container to set expectations on them.
+// If AutoMock is used multiple times, the factories are tried in the order they were given.
+func AutoMock(factory MockFactory) Option {
+	return func(c *config) {
+		c.mockFactories = append(c.mockFactories, factory)
+	}
+}
+
+// BuildFor builds the container with only the subgraph of services that T needs.
+// Any definition that T does not depend on (directly or transitively) is dropped
+// before validation, so it is neither validated nor eagerly instantiated.
+// It returns the service of type T and the container. Any error fails the test.
+// The container is built from a clone of the builder, so the builder can be reused, e.g. for other services.
+func BuildFor[T any](t testing.TB, builder *godi.Builder, opts ...Option) (T, godi.Container) {
+	t.Helper()
+
+	conf := new(config)
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	builder, err := builder.Clone()
+	require.NoError(t, err)
+
+	typ := reflect.TypeFor[T]()
+	roots := func(builder *di.ContainerBuilder) ([]di.ID, error) {
+		ids := di.ResolveArgIDs(builder.RootScope(), di.NewTypeArg(typ, false))
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("no services found for type %s", util.Signature(typ))
+		}
+		return ids, nil
+	}
+
+	builder.CompilerPasses(di.NewCompilerPass("subgraph", di.PreValidation, di.CompilerOpFunc(func(builder *di.ContainerBuilder) error {
+		if len(conf.mockFactories) > 0 {
+			ids, err := roots(builder)
+			if err != nil {
+				return err
+			}
+			err = (&autoMocker{t: t, factories: conf.mockFactories}).mockMissing(builder, ids)
+			if err != nil {
+				return errorsx.Wrap(err, "failed to create mocks")
+			}
+		}
+		return di.NewPruningPass(roots).Run(builder)
+	})))
+
+	c, err := builder.Build()
+	require.NoError(t, err)
+
+	svc, err := godi.SvcByType[T](c)
+	require.NoError(t, err)
+
+	return svc, c
+}
+
+type autoMocker struct {
+	t         testing.TB
+	factories []MockFactory
+}
+
+func (m *autoMocker) mockMissing(builder *di.ContainerBuilder, roots []di.ID) error {
+	ids, err := di.ReachableIDs(builder, roots...)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		def, ok := builder.ServiceDefinition(id)
+		if !ok {
+			continue
+		}
+		if err := m.mockArgs(builder.RootScope(), def.EffectiveScope(), def.Factory().Args()); err != nil {
+			return errorsx.Wrapf(err, "service %s", def)
+		}
+		for _, method := range def.MethodCalls() {
+			if err := m.mockArgs(builder.RootScope(), def.EffectiveScope(), method.Args()); err != nil {
+				return errorsx.Wrapf(err, "method %s", method)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *autoMocker) mockArgs(root, scope *di.Scope, args *di.ArgList) error {
+	for _, slot := range args.Slots() {
+		arg := slot.Arg()
+		if arg == nil || arg.Type().Kind() != reflect.Interface {
+			continue
+		}
+		if di.ValidateArg(scope, arg) == nil {
+			continue // Satisfied by a registered service or an already created mock.
+		}
+		if err := m.mock(root, arg.Type()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *autoMocker) mock(root *di.Scope, iface reflect.Type) error {
+	var mock any
+	for _, factory := range m.factories {
+		if mock = factory(m.t, iface); mock != nil {
+			break
+		}
+	}
+	if mock == nil {
+		return nil // Not mockable, the argument validation will report the missing dependency.
+	}
+
+	mockTyp := reflect.TypeOf(mock)
+	if !mockTyp.Implements(iface) {
+		return fmt.Errorf("mock %s does not implement %s", util.Signature(mockTyp), util.Signature(iface))
+	}
+
+	fn := reflect.MakeFunc(reflect.FuncOf(nil, []reflect.Type{mockTyp}, false), func([]reflect.Value) []reflect.Value {
+		return []reflect.Value{reflect.ValueOf(mock)}
+	})
+	factory, err := di.NewFactory(fn.Interface())
+	if err != nil {
+		return err
+	}
+	def := di.NewServiceDefinition(factory).SetScope(root)
+	root.AddServiceDefinitions(def)
+
+	ref, err := di.NewRefArg(def)
+	if err != nil {
+		return err
+	}
+	binding, err := di.NewInterfaceBinding(iface, ref)
+	if err != nil {
+		return err
+	}
+	root.AddBindings(binding)
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package ditest_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	di "github.com/michalkurzeja/godi/v2"
+	"github.com/michalkurzeja/godi/v2/ditest"
+	"github.com/michalkurzeja/godi/v2/mocks"
+)
+
+type Consumer struct {
+	c di.Container
+}
+
+func NewConsumer(c di.Container) *Consumer {
+	return &Consumer{c: c}
+}
+
+func (c *Consumer) HasService(id di.ID) bool {
+	return c.c.HasService(id)
+}
+
+type Unrelated struct{}
+
+func NewUnrelated(string) *Unrelated {
+	return &Unrelated{}
+}
+
+func mockContainer(t testing.TB, iface reflect.Type) any {
+	if iface == reflect.TypeFor[di.Container]() {
+		return mocks.NewContainer(t)
+	}
+	return nil
+}
+
+func TestBuildFor(t *testing.T) {
+	t.Run("builds only the subgraph of the requested service", func(t *testing.T) {
+		t.Parallel()
+
+		var eagerCalled bool
+
+		builder := di.New().Services(
+			di.SvcVal("foo"),
+			di.Svc(func(s string) []string { return []string{s} }),
+			di.Svc(NewUnrelated), // Would fail the validation if it wasn't dropped.
+			di.Svc(func() int { eagerCalled = true; return 42 }).Eager(),
+		)
+
+		got, c := ditest.BuildFor[[]string](t, builder)
+		require.Equal(t, []string{"foo"}, got)
+		require.False(t, eagerCalled)
+
+		_, err := di.SvcByType[int](c)
+		require.ErrorContains(t, err, "service of type int not found")
+	})
+	t.Run("leaves the builder intact", func(t *testing.T) {
+		t.Parallel()
+
+		builder := di.New().Services(
+			di.SvcVal("foo"),
+			di.Svc(func(s string) []string { return []string{s} }),
+			di.Svc(func(s string) int { return len(s) }),
+		)
+
+		got, _ := ditest.BuildFor[[]string](t, builder)
+		require.Equal(t, []string{"foo"}, got)
+		n, _ := ditest.BuildFor[int](t, builder)
+		require.Equal(t, 3, n)
+
+		c, err := builder.Build()
+		require.NoError(t, err)
+		require.Len(t, c.GetServicesIDsByType(reflect.TypeFor[[]string]()), 1)
+		require.Len(t, c.GetServicesIDsByType(reflect.TypeFor[int]()), 1)
+	})
+	t.Run("mocks missing interface dependencies", func(t *testing.T) {
+		t.Parallel()
+
+		builder := di.New().Services(
+			di.Svc(NewConsumer),
+		)
+
+		consumer, c := ditest.BuildFor[*Consumer](t, builder, ditest.AutoMock(mockContainer))
+
+		m, err := di.SvcByType[*mocks.Container](c)
+		require.NoError(t, err)
+		m.EXPECT().HasService(di.ID("foo")).Return(true).Once()
+
+		require.True(t, consumer.HasService("foo"))
+	})
+	t.Run("does not mock dependencies that have an implementation", func(t *testing.T) {
+		t.Parallel()
+
+		impl := mocks.NewContainer(t)
+		builder := di.New().Services(
+			di.SvcVal(impl),
+			di.Svc(NewConsumer),
+		)
+
+		consumer, c := ditest.BuildFor[*Consumer](t, builder, ditest.AutoMock(mockContainer))
+		require.Same(t, impl, consumer.c)
+
+		svcs, err := di.SvcsByType[*mocks.Container](c)
+		require.NoError(t, err)
+		require.Len(t, svcs, 1)
+	})
+	t.Run("leaves dependencies unresolved when the factory cannot mock them", func(t *testing.T) {
+		t.Parallel()
+
+		builder := di.New().Services(
+			di.Svc(NewConsumer),
+		)
+
+		ft := new(fakeT)
+		require.Panics(t, func() {
+			ditest.BuildFor[*Consumer](ft, builder, ditest.AutoMock(func(testing.TB, reflect.Type) any { return nil }))
+		})
+		require.Contains(t, ft.msg, "no services found for type github.com/michalkurzeja/godi/v2.Container")
+	})
+}
+
+// fakeT captures the failure of a test helper without failing the actual test.
+type fakeT struct {
+	testing.TB
+	msg string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Name() string { return "fake" }
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.msg = fmt.Sprintf(format, args...)
+}
+
+func (t *fakeT) FailNow() {
+	panic("FailNow")
+}
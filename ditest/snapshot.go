@@ -0,0 +1,63 @@
+package ditest
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	godi "github.com/michalkurzeja/godi/v2"
+	"github.com/michalkurzeja/godi/v2/di"
+)
+
+// UpdateSnapshotsEnv is the environment variable that makes AssertGraphSnapshot write the golden files
+// instead of comparing with them, e.g. GODI_UPDATE_SNAPSHOTS=1 go test ./...
+const UpdateSnapshotsEnv = "GODI_UPDATE_SNAPSHOTS"
+
+func updateGoldenFiles() bool {
+	update, _ := strconv.ParseBool(os.Getenv(UpdateSnapshotsEnv))
+	return update
+}
+
+// errSnapshotTaken stops the build once the snapshot is taken, so that no services are instantiated.
+var errSnapshotTaken = errors.New("graph snapshot taken")
+
+// AssertGraphSnapshot compiles the container and compares the description of its resolved graph
+// (see di.Describe) with the contents of the golden file at the given path.
+// Set the UpdateSnapshotsEnv environment variable to write the current description to the golden file instead.
+// A clone of the builder is compiled up to the finalization stage, so no services are instantiated
+// (eager services included), and the builder itself is left unchanged.
+func AssertGraphSnapshot(t testing.TB, builder *godi.Builder, path string) {
+	t.Helper()
+
+	builder, err := builder.Clone()
+	require.NoError(t, err)
+
+	var got strings.Builder
+	// The snapshot runs last in its stage, so that it includes the changes of other pre-finalization passes.
+	builder.CompilerPasses(di.NewCompilerPass("graph snapshot", di.PreFinalization, di.CompilerOpFunc(func(builder *di.ContainerBuilder) error {
+		di.Describe(builder.Scopes(), &got)
+		return errSnapshotTaken
+	})).WithPriority(math.MinInt))
+
+	_, err = builder.Build()
+	if !errors.Is(err, errSnapshotTaken) {
+		require.NoError(t, err)
+		require.FailNow(t, "graph snapshot was not taken")
+	}
+
+	if updateGoldenFiles() {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got.String()), 0o644))
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "failed to read the golden file, run the tests with %s=1 to create it", UpdateSnapshotsEnv)
+	require.Equal(t, string(want), got.String(), "the graph differs from the golden file %s, run the tests with %s=1 if the change is intended", path, UpdateSnapshotsEnv)
+}
@@ -0,0 +1,105 @@
+package ditest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	di "github.com/michalkurzeja/godi/v2"
+	"github.com/michalkurzeja/godi/v2/ditest"
+)
+
+type Greeter interface {
+	Greet() string
+}
+
+type EnglishGreeter struct {
+	name string
+}
+
+func NewEnglishGreeter(name string) *EnglishGreeter {
+	return &EnglishGreeter{name: name}
+}
+
+func (g *EnglishGreeter) Greet() string {
+	return "Hello, " + g.name
+}
+
+func (g *EnglishGreeter) SetName(name string) {
+	g.name = name
+}
+
+type Welcome struct {
+	greeter Greeter
+}
+
+func NewWelcome(greeter Greeter) *Welcome {
+	return &Welcome{greeter: greeter}
+}
+
+func newSnapshotBuilder(eagerCalled *bool) *di.Builder {
+	return di.New().
+		Services(
+			di.Svc(NewEnglishGreeter, "world").
+				MethodCall((*EnglishGreeter).SetName, di.Named[string]("name")).
+				Labels("greeter"),
+			di.SvcVal("John").Name("name"),
+			di.Svc(NewWelcome).Eager().Children(
+				di.SvcVal(42),
+			),
+			di.Svc(func() float64 { *eagerCalled = true; return 0 }).Eager(),
+		).
+		Functions(
+			di.Func(func(*Welcome) {}).Labels("handler"),
+		)
+}
+
+func TestAssertGraphSnapshot(t *testing.T) {
+	t.Run("matches the golden file", func(t *testing.T) {
+		t.Parallel()
+
+		var eagerCalled bool
+		ditest.AssertGraphSnapshot(t, newSnapshotBuilder(&eagerCalled), "testdata/container.golden")
+		require.False(t, eagerCalled)
+	})
+	t.Run("fails when the graph differs from the golden file", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "container.golden")
+		require.NoError(t, os.WriteFile(path, []byte("scope root\n"), 0o644))
+
+		ft := new(fakeT)
+		var eagerCalled bool
+		require.Panics(t, func() {
+			ditest.AssertGraphSnapshot(ft, newSnapshotBuilder(&eagerCalled), path)
+		})
+		require.Contains(t, ft.msg, "the graph differs from the golden file")
+	})
+	t.Run("leaves the builder intact", func(t *testing.T) {
+		t.Parallel()
+
+		var eagerCalled bool
+		builder := newSnapshotBuilder(&eagerCalled)
+		ditest.AssertGraphSnapshot(t, builder, "testdata/container.golden")
+		require.False(t, eagerCalled)
+
+		_, err := builder.Build()
+		require.NoError(t, err)
+		require.True(t, eagerCalled)
+	})
+	t.Run("updates the golden file if requested", func(t *testing.T) {
+		t.Setenv(ditest.UpdateSnapshotsEnv, "1")
+
+		path := filepath.Join(t.TempDir(), "testdata", "container.golden")
+		var eagerCalled bool
+		ditest.AssertGraphSnapshot(t, newSnapshotBuilder(&eagerCalled), path)
+
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		want, err := os.ReadFile("testdata/container.golden")
+		require.NoError(t, err)
+		require.Equal(t, string(want), string(got))
+	})
+}
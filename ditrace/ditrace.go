@@ -0,0 +1,147 @@
+// Package ditrace emits OpenTelemetry spans for the calls made by the container.
+package ditrace
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/michalkurzeja/godi/v2/di"
+	"github.com/michalkurzeja/godi/v2/internal/util"
+)
+
+const instrumentationName = "github.com/michalkurzeja/godi/v2/ditrace"
+
+// Attribute keys of the emitted spans.
+const (
+	CallKindKey = attribute.Key("godi.call_kind")
+	TypeKey     = attribute.Key("godi.type")
+	LabelsKey   = attribute.Key("godi.labels")
+	ScopeKey    = attribute.Key("godi.scope")
+	MethodKey   = attribute.Key("godi.method")
+	CacheHitKey = attribute.Key("godi.cache_hit")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	parent         context.Context
+}
+
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider used to create the spans.
+// By default, the global tracer provider is used.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithParentContext sets the context that the top-level spans are created in,
+// e.g. to attach them to the span of a cold start.
+// By default, the top-level spans are root spans. The top-level spans of resolutions started with
+// a context that carries a span (e.g. di.SvcByTypeAsync) are created in that context instead.
+func WithParentContext(ctx context.Context) Option {
+	return func(c *config) {
+		c.parent = ctx
+	}
+}
+
+// NewInterceptor returns an interceptor that emits a span for every service instantiation,
+// method call and function execution, and a zero-length span for every retrieval of an
+// already instantiated shared service (cache hit).
+// The spans are nested along the dependency path: the instantiation of a dependency
+// is a child of the call that needed it.
+func NewInterceptor(opts ...Option) di.Interceptor {
+	conf := &config{parent: context.Background()}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	if conf.tracerProvider == nil {
+		conf.tracerProvider = otel.GetTracerProvider()
+	}
+
+	return &interceptor{
+		tracer: conf.tracerProvider.Tracer(instrumentationName),
+		parent: conf.parent,
+		spans:  make(map[uint64]context.Context),
+	}
+}
+
+type interceptor struct {
+	tracer trace.Tracer
+	parent context.Context
+
+	// The contexts of the spans of the unfinished calls, by call ID.
+	// A nested call is found a parent by its Call.Parent.
+	mu    sync.Mutex
+	spans map[uint64]context.Context
+}
+
+func (i *interceptor) Before(call di.Call) {
+	i.mu.Lock()
+	ctx, ok := i.spans[call.Parent]
+	i.mu.Unlock()
+	if !ok {
+		ctx = i.parentOf(call)
+	}
+
+	ctx, _ = i.tracer.Start(ctx, call.String(), trace.WithAttributes(attributes(call)...))
+
+	i.mu.Lock()
+	i.spans[call.ID] = ctx
+	i.mu.Unlock()
+}
+
+func (i *interceptor) After(call di.Call, _ time.Duration, err error) {
+	i.mu.Lock()
+	ctx, ok := i.spans[call.ID]
+	delete(i.spans, call.ID)
+	i.mu.Unlock()
+	if !ok {
+		return // Before was not called, e.g. the interceptor was added mid-call.
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// parentOf returns the context to create the span of a top-level call in.
+func (i *interceptor) parentOf(call di.Call) context.Context {
+	if call.Context != nil && trace.SpanContextFromContext(call.Context).IsValid() {
+		return call.Context
+	}
+	return i.parent
+}
+
+func attributes(call di.Call) []attribute.KeyValue {
+	labels := make([]string, len(call.Definition.Labels()))
+	for i, label := range call.Definition.Labels() {
+		labels[i] = label.String()
+	}
+
+	attrs := []attribute.KeyValue{
+		CallKindKey.String(call.Kind.String()),
+		TypeKey.String(util.Signature(call.Definition.Type())),
+		LabelsKey.StringSlice(labels),
+		ScopeKey.String(call.Scope.String()),
+	}
+	switch call.Kind {
+	case di.ServiceInstantiation:
+		attrs = append(attrs, CacheHitKey.Bool(false))
+	case di.ServiceCacheHit:
+		attrs = append(attrs, CacheHitKey.Bool(true))
+	case di.MethodCall:
+		attrs = append(attrs, MethodKey.String(call.Method.String()))
+	}
+	return attrs
+}
@@ -0,0 +1,209 @@
+package ditrace_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	di "github.com/michalkurzeja/godi/v2"
+	"github.com/michalkurzeja/godi/v2/ditrace"
+)
+
+type Foo struct{}
+
+type Baz struct {
+	foo *Foo
+}
+
+func NewBaz(foo *Foo) *Baz {
+	return &Baz{foo: foo}
+}
+
+type Bar struct {
+	foo *Foo
+}
+
+func NewBar(foo *Foo) *Bar {
+	return &Bar{foo: foo}
+}
+
+func newTracerProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), exporter
+}
+
+func attrs(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestNewInterceptor(t *testing.T) {
+	t.Run("emits nested spans", func(t *testing.T) {
+		t.Parallel()
+
+		tp, exporter := newTracerProvider()
+
+		c, err := di.New(di.Interceptors(ditrace.NewInterceptor(ditrace.WithTracerProvider(tp)))).
+			Services(
+				di.Svc(func() *Foo { return &Foo{} }).Labels("foo"),
+				di.Svc(NewBar),
+			).
+			Build()
+		require.NoError(t, err)
+
+		_, err = di.SvcByType[*Bar](c)
+		require.NoError(t, err)
+		_, err = di.SvcByType[*Foo](c)
+		require.NoError(t, err)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 3)
+
+		foo, bar, hit := spans[0], spans[1], spans[2]
+
+		require.Equal(t, "service instantiation github.com/michalkurzeja/godi/v2/ditrace_test.(*Bar)", bar.Name)
+		require.False(t, bar.Parent.IsValid())
+		barAttrs := attrs(bar)
+		require.Equal(t, "service instantiation", barAttrs[ditrace.CallKindKey].AsString())
+		require.Equal(t, "github.com/michalkurzeja/godi/v2/ditrace_test.(*Bar)", barAttrs[ditrace.TypeKey].AsString())
+		require.Equal(t, "root", barAttrs[ditrace.ScopeKey].AsString())
+		require.False(t, barAttrs[ditrace.CacheHitKey].AsBool())
+
+		require.Equal(t, bar.SpanContext.SpanID(), foo.Parent.SpanID())
+		require.Equal(t, bar.SpanContext.TraceID(), foo.SpanContext.TraceID())
+		fooAttrs := attrs(foo)
+		require.Equal(t, []string{"foo"}, fooAttrs[ditrace.LabelsKey].AsStringSlice())
+		require.False(t, fooAttrs[ditrace.CacheHitKey].AsBool())
+
+		require.Equal(t, "service cache hit github.com/michalkurzeja/godi/v2/ditrace_test.(*Foo) (foo)", hit.Name)
+		require.False(t, hit.Parent.IsValid())
+		require.True(t, attrs(hit)[ditrace.CacheHitKey].AsBool())
+	})
+	t.Run("records errors", func(t *testing.T) {
+		t.Parallel()
+
+		tp, exporter := newTracerProvider()
+
+		c, err := di.New(di.Interceptors(ditrace.NewInterceptor(ditrace.WithTracerProvider(tp)))).
+			Services(
+				di.Svc(func() (*Foo, error) { return nil, errors.New("oops") }),
+			).
+			Functions(
+				di.Func(func(*Foo) {}),
+			).
+			Build()
+		require.NoError(t, err)
+
+		_, err = di.ExecByType[func(*Foo)](c)
+		require.ErrorContains(t, err, "oops")
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 2)
+		require.Equal(t, "service instantiation", attrs(spans[0])[ditrace.CallKindKey].AsString())
+		require.Equal(t, "function call", attrs(spans[1])[ditrace.CallKindKey].AsString())
+		require.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+		require.Equal(t, codes.Error, spans[1].Status.Code)
+		require.Equal(t, codes.Error, spans[0].Status.Code)
+		require.Len(t, spans[0].Events, 1)
+	})
+	t.Run("nests the spans of concurrent resolutions separately", func(t *testing.T) {
+		t.Parallel()
+
+		tp, exporter := newTracerProvider()
+
+		// Both dependencies wait for each other, so that the resolutions are in progress at the same time.
+		var arrived sync.WaitGroup
+		arrived.Add(2)
+		newFoo := func() *Foo {
+			arrived.Done()
+			arrived.Wait()
+			return &Foo{}
+		}
+
+		c, err := di.New(di.Interceptors(ditrace.NewInterceptor(ditrace.WithTracerProvider(tp)))).
+			Services(
+				di.Svc(newFoo).Labels("bar"),
+				di.Svc(newFoo).Labels("baz"),
+				di.Svc(NewBar, di.Type[*Foo]("bar")),
+				di.Svc(NewBaz, di.Type[*Foo]("baz")),
+			).
+			Build()
+		require.NoError(t, err)
+
+		bar := di.SvcByTypeAsync[*Bar](context.Background(), c)
+		baz := di.SvcByTypeAsync[*Baz](context.Background(), c)
+		require.NoError(t, (<-bar).Err)
+		require.NoError(t, (<-baz).Err)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 4)
+		byName := make(map[string]tracetest.SpanStub, len(spans))
+		for _, span := range spans {
+			byName[span.Name] = span
+		}
+
+		for parent, child := range map[string]string{
+			"service instantiation github.com/michalkurzeja/godi/v2/ditrace_test.(*Bar)": "service instantiation github.com/michalkurzeja/godi/v2/ditrace_test.(*Foo) (bar)",
+			"service instantiation github.com/michalkurzeja/godi/v2/ditrace_test.(*Baz)": "service instantiation github.com/michalkurzeja/godi/v2/ditrace_test.(*Foo) (baz)",
+		} {
+			require.Contains(t, byName, parent)
+			require.Contains(t, byName, child)
+			require.False(t, byName[parent].Parent.IsValid(), "%s should be a root span", parent)
+			require.Equal(t, byName[parent].SpanContext.SpanID(), byName[child].Parent.SpanID(), "%s should be a child of %s", child, parent)
+			require.False(t, byName[child].EndTime.After(byName[parent].EndTime), "%s should end before %s", child, parent)
+		}
+	})
+	t.Run("attaches the spans of async resolutions to the trace of the caller", func(t *testing.T) {
+		t.Parallel()
+
+		tp, exporter := newTracerProvider()
+
+		c, err := di.New(di.Interceptors(ditrace.NewInterceptor(ditrace.WithTracerProvider(tp)))).
+			Services(
+				di.Svc(func() *Foo { return &Foo{} }),
+				di.Svc(NewBar),
+			).
+			Build()
+		require.NoError(t, err)
+
+		ctx, caller := tp.Tracer("test").Start(context.Background(), "caller")
+		require.NoError(t, (<-di.SvcByTypeAsync[*Bar](ctx, c)).Err)
+		caller.End()
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 3)
+		require.Equal(t, "caller", spans[2].Name)
+		require.Equal(t, spans[2].SpanContext.SpanID(), spans[1].Parent.SpanID())
+		require.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+		require.Equal(t, spans[2].SpanContext.TraceID(), spans[0].SpanContext.TraceID())
+	})
+	t.Run("ends the spans of panicking calls", func(t *testing.T) {
+		t.Parallel()
+
+		tp, exporter := newTracerProvider()
+
+		c, err := di.New(di.Interceptors(ditrace.NewInterceptor(ditrace.WithTracerProvider(tp)))).
+			Services(
+				di.Svc(func() *Foo { panic("oops") }),
+			).
+			Build()
+		require.NoError(t, err)
+
+		require.PanicsWithValue(t, "oops", func() { _, _ = di.SvcByType[*Foo](c) })
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		require.Equal(t, codes.Error, spans[0].Status.Code)
+		require.Contains(t, spans[0].Status.Description, "panicked")
+	})
+}
@@ -0,0 +1,576 @@
+// Package divet provides a static analyzer of godi definitions.
+//
+// The analyzer reports the definition errors that would otherwise only be caught by Builder.Build,
+// using the same rules as the container: invalid factory, function and method signatures,
+// methods not found on the service type, arguments that fit no slot (including Slot(n) out of range)
+// and invalid interface bindings. Definitions that cannot be fully understood statically
+// (e.g. arguments stored in variables) are checked only as far as they can be, so the analyzer reports no false positives.
+package divet
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const godiPath = "github.com/michalkurzeja/godi/v2"
+
+// Analyzer reports invalid godi definitions.
+var Analyzer = &analysis.Analyzer{
+	Name:     "godivet",
+	Doc:      "reports invalid godi service, function and binding definitions",
+	URL:      "https://pkg.go.dev/github.com/michalkurzeja/godi/v2/divet",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	v := &vet{pass: pass, errType: types.Universe.Lookup("error").Type()}
+
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		switch fn := v.godiFunc(call); fn {
+		case "Svc":
+			v.checkSvc(call)
+		case "Func":
+			v.checkFunc(call)
+		case "ServiceDefinitionBuilder.MethodCall":
+			v.checkMethodCall(call)
+		case "Compound":
+			v.checkCompound(call)
+		case "BindType", "BindSlice", "BindArg":
+			v.checkBinding(call, fn)
+		}
+	})
+
+	return nil, nil
+}
+
+type vet struct {
+	pass    *analysis.Pass
+	errType types.Type
+}
+
+// godiFunc returns the name of the godi function called by the expression, with the receiver type name for methods.
+// It returns an empty string if the call is not a call to godi.
+func (v *vet) godiFunc(call *ast.CallExpr) string {
+	fn, ok := typeutil.Callee(v.pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != godiPath {
+		return ""
+	}
+	recv := fn.Signature().Recv()
+	if recv == nil {
+		return fn.Name()
+	}
+	typ := recv.Type()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return ""
+	}
+	return named.Obj().Name() + "." + fn.Name()
+}
+
+// typeArg returns the n-th type argument of a call to a generic godi function.
+func (v *vet) typeArg(call *ast.CallExpr, n int) types.Type {
+	fun := ast.Unparen(call.Fun)
+	switch idx := fun.(type) {
+	case *ast.IndexExpr:
+		fun = idx.X
+	case *ast.IndexListExpr:
+		fun = idx.X
+	}
+	var id *ast.Ident
+	switch f := fun.(type) {
+	case *ast.Ident:
+		id = f
+	case *ast.SelectorExpr:
+		id = f.Sel
+	default:
+		return nil
+	}
+	inst, ok := v.pass.TypesInfo.Instances[id]
+	if !ok || inst.TypeArgs.Len() <= n {
+		return nil
+	}
+	return concrete(inst.TypeArgs.At(n))
+}
+
+// concrete returns the type, or nil if it depends on type parameters, so it's not known statically.
+func concrete(typ types.Type) types.Type {
+	if hasTypeParams(typ, make(map[types.Type]bool)) {
+		return nil
+	}
+	return typ
+}
+
+func hasTypeParams(typ types.Type, seen map[types.Type]bool) bool {
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+
+	switch t := typ.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Pointer:
+		return hasTypeParams(t.Elem(), seen)
+	case *types.Slice:
+		return hasTypeParams(t.Elem(), seen)
+	case *types.Array:
+		return hasTypeParams(t.Elem(), seen)
+	case *types.Chan:
+		return hasTypeParams(t.Elem(), seen)
+	case *types.Map:
+		return hasTypeParams(t.Key(), seen) || hasTypeParams(t.Elem(), seen)
+	case *types.Named:
+		for i := range t.TypeArgs().Len() {
+			if hasTypeParams(t.TypeArgs().At(i), seen) {
+				return true
+			}
+		}
+	case *types.Signature:
+		return hasTypeParams(t.Params(), seen) || hasTypeParams(t.Results(), seen)
+	case *types.Tuple:
+		for i := range t.Len() {
+			if hasTypeParams(t.At(i).Type(), seen) {
+				return true
+			}
+		}
+	case *types.Struct:
+		for i := range t.NumFields() {
+			if hasTypeParams(t.Field(i).Type(), seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *vet) checkSvc(call *ast.CallExpr) {
+	if len(call.Args) == 0 {
+		return
+	}
+	sig, ok := v.funcSignature(call.Args[0], "factory")
+	if !ok {
+		return
+	}
+	name := types.ExprString(call.Args[0])
+	switch res := sig.Results(); {
+	case res.Len() < 1:
+		v.pass.Reportf(call.Args[0].Pos(), "factory %s must return at least one value", name)
+		return
+	case res.Len() > 2:
+		v.pass.Reportf(call.Args[0].Pos(), "factory %s must return at most two values", name)
+		return
+	case res.Len() == 2 && !types.AssignableTo(res.At(1).Type(), v.errType):
+		v.pass.Reportf(call.Args[0].Pos(), "factory %s may only return an error as a second return value, not %s", name, v.typeString(res.At(1).Type()))
+		return
+	}
+	v.checkArgs(call, sig, nil)
+}
+
+func (v *vet) checkFunc(call *ast.CallExpr) {
+	if len(call.Args) == 0 {
+		return
+	}
+	sig, ok := v.funcSignature(call.Args[0], "function")
+	if !ok {
+		return
+	}
+	v.checkArgs(call, sig, nil)
+}
+
+func (v *vet) checkMethodCall(call *ast.CallExpr) {
+	if len(call.Args) == 0 {
+		return
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+	svcType := v.serviceType(sel.X)
+	if svcType == nil {
+		return
+	}
+	sig, ok := v.funcSignature(call.Args[0], "method")
+	if !ok {
+		return
+	}
+
+	fnExpr := ast.Unparen(call.Args[0])
+	name := types.ExprString(fnExpr)
+	if short, ok := v.funcNameShort(fnExpr); ok && !hasMethod(svcType, short) {
+		v.pass.Reportf(fnExpr.Pos(), "method %s not found on receiver %s", name, v.typeString(svcType))
+		return
+	}
+	switch res := sig.Results(); {
+	case res.Len() > 1:
+		v.pass.Reportf(fnExpr.Pos(), "method %s must return at most one value", name)
+		return
+	case res.Len() == 1 && !types.AssignableTo(res.At(0).Type(), v.errType):
+		v.pass.Reportf(fnExpr.Pos(), "method %s may only return an error, not %s", name, v.typeString(res.At(0).Type()))
+		return
+	}
+	v.checkArgs(call, sig, &arg{expr: fnExpr, typ: svcType, slot: 0})
+}
+
+func (v *vet) checkCompound(call *ast.CallExpr) {
+	typ := v.typeArg(call, 0)
+	if typ == nil || call.Ellipsis.IsValid() {
+		return
+	}
+	for _, expr := range call.Args {
+		if a := v.parseArg(expr); a.typ != nil && !types.AssignableTo(a.typ, typ) {
+			v.pass.Reportf(expr.Pos(), "argument %s cannot be assigned to type %s", v.typeString(a.typ), v.typeString(typ))
+		}
+	}
+}
+
+func (v *vet) checkBinding(call *ast.CallExpr, fn string) {
+	iface := v.typeArg(call, 0)
+	if iface == nil {
+		return
+	}
+	if !types.IsInterface(iface) {
+		v.pass.Reportf(call.Pos(), "invalid binding: %s is not an interface", v.typeString(iface))
+		return
+	}
+
+	var boundTo types.Type
+	switch fn {
+	case "BindType":
+		boundTo = v.typeArg(call, 1)
+	case "BindSlice":
+		// The type is bound via a compound argument of the interface type.
+		if to := v.typeArg(call, 1); to != nil && !types.AssignableTo(to, iface) {
+			v.pass.Reportf(call.Pos(), "argument %s cannot be assigned to type %s", v.typeString(to), v.typeString(iface))
+		}
+		return
+	case "BindArg":
+		if len(call.Args) == 1 {
+			boundTo = v.parseArg(call.Args[0]).typ
+		}
+	}
+	if boundTo != nil && !types.Implements(boundTo, iface.Underlying().(*types.Interface)) {
+		v.pass.Reportf(call.Pos(), "invalid binding: %s does not implement %s", v.typeString(boundTo), v.typeString(iface))
+	}
+}
+
+// funcSignature returns the signature of a function passed to godi.
+// It reports an error if the expression is statically known not to be a function.
+func (v *vet) funcSignature(expr ast.Expr, kind string) (*types.Signature, bool) {
+	tv, ok := v.pass.TypesInfo.Types[expr]
+	if !ok || tv.Type == nil || tv.IsNil() || concrete(tv.Type) == nil {
+		return nil, false
+	}
+	switch typ := tv.Type.Underlying().(type) {
+	case *types.Signature:
+		return typ, true
+	case *types.Interface:
+		return nil, false // The dynamic type is unknown.
+	default:
+		v.pass.Reportf(expr.Pos(), "%s kind must be func, got %s", kind, v.typeString(tv.Type))
+		return nil, false
+	}
+}
+
+// funcNameShort returns the short name of the function, as it's known to the runtime.
+func (v *vet) funcNameShort(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.FuncLit:
+		return "func1", true // Closures never match a method name.
+	case *ast.Ident:
+		if fn, ok := v.pass.TypesInfo.Uses[e].(*types.Func); ok {
+			return fn.Name(), true
+		}
+	case *ast.SelectorExpr:
+		if sel, ok := v.pass.TypesInfo.Selections[e]; ok {
+			switch sel.Kind() {
+			case types.MethodExpr:
+				return e.Sel.Name, true
+			case types.MethodVal:
+				return e.Sel.Name + "-fm", true // Method values are wrapped by the compiler.
+			case types.FieldVal:
+			}
+			return "", false
+		}
+		if fn, ok := v.pass.TypesInfo.Uses[e.Sel].(*types.Func); ok {
+			return fn.Name(), true // Qualified package-level function.
+		}
+	case *ast.IndexExpr:
+		return v.funcNameShort(ast.Unparen(e.X))
+	case *ast.IndexListExpr:
+		return v.funcNameShort(ast.Unparen(e.X))
+	}
+	return "", false
+}
+
+// hasMethod reports whether the method is found by reflect.Type.MethodByName, i.e. it's exported and in the method set.
+func hasMethod(typ types.Type, name string) bool {
+	if !ast.IsExported(name) {
+		return false
+	}
+	obj, _, _ := types.LookupFieldOrMethod(typ, false, nil, name)
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// serviceType returns the type of the service defined by a chain of ServiceDefinitionBuilder calls.
+func (v *vet) serviceType(expr ast.Expr) types.Type {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	switch fn := v.godiFunc(call); {
+	case fn == "Svc":
+		if len(call.Args) == 0 {
+			return nil
+		}
+		sig, ok := v.pass.TypesInfo.TypeOf(call.Args[0]).(*types.Signature)
+		if !ok || sig.Results().Len() == 0 {
+			return nil
+		}
+		return concrete(sig.Results().At(0).Type())
+	case fn == "SvcVal" || strings.HasPrefix(fn, "Provide"):
+		return v.typeArg(call, 0)
+	case strings.HasPrefix(fn, "ServiceDefinitionBuilder."):
+		sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+		if !ok {
+			return nil
+		}
+		return v.serviceType(sel.X)
+	}
+	return nil
+}
+
+type arg struct {
+	expr ast.Expr
+	typ  types.Type // Nil if the type is not known statically.
+	slot int        // -1 if the argument is not slotted, -2 if the slot is not known statically.
+}
+
+// parseArg statically evaluates an argument passed to godi, the same way as godi.Arg does it at runtime.
+func (v *vet) parseArg(expr ast.Expr) arg {
+	a := arg{expr: expr, slot: -1}
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return v.parseArgValue(a, expr)
+	}
+	for ok {
+		switch fn := v.godiFunc(call); fn {
+		case "ArgBuilder.Slot":
+			if a.slot == -1 { // The last call wins.
+				a.slot = v.constSlot(call.Args[0])
+			}
+		case "ArgBuilder.Reverse":
+		case "Arg":
+			return v.parseArgValue(a, call.Args[0])
+		case "Type", "Named", "Compound":
+			a.typ = v.typeArg(call, 0)
+			return a
+		case "SliceOf":
+			if typ := v.typeArg(call, 0); typ != nil {
+				a.typ = types.NewSlice(typ)
+			}
+			return a
+		case "MapOf":
+			if k, val := v.typeArg(call, 0), v.typeArg(call, 1); k != nil && val != nil {
+				a.typ = types.NewMap(k, val)
+			}
+			return a
+		case "Val":
+			a.typ = v.literalType(call.Args[0])
+			return a
+		case "TypedRef.Arg":
+			a.typ = v.typedRefType(v.pass.TypesInfo.TypeOf(call.Fun.(*ast.SelectorExpr).X))
+			return a
+		case "Ref":
+			return a
+		default:
+			return v.parseArgValue(a, call)
+		}
+		sel, isSel := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+		if !isSel {
+			break
+		}
+		call, ok = ast.Unparen(sel.X).(*ast.CallExpr)
+	}
+	if !ok && a.slot == -1 {
+		a.slot = -2 // A builder held in a variable may have been slotted.
+	}
+	return a
+}
+
+// parseArgValue evaluates a value that is not an argument builder call.
+func (v *vet) parseArgValue(a arg, expr ast.Expr) arg {
+	typ := v.pass.TypesInfo.TypeOf(expr)
+	if typ == nil {
+		return arg{expr: a.expr, slot: -2}
+	}
+	if isGodiType(typ, "ArgBuilder") {
+		return arg{expr: a.expr, slot: -2}
+	}
+	if isGodiType(typ, "SvcReference") {
+		return a // A reference to a service of an unknown type.
+	}
+	if t := v.typedRefType(typ); t != nil {
+		a.typ = t
+		return a
+	}
+	a.typ = v.literalType(expr)
+	return a
+}
+
+// literalType returns the dynamic type of a literal value, if it's known statically.
+func (v *vet) literalType(expr ast.Expr) types.Type {
+	tv, ok := v.pass.TypesInfo.Types[expr]
+	if !ok || tv.IsNil() || types.IsInterface(tv.Type) {
+		return nil
+	}
+	if basic, ok := tv.Type.(*types.Basic); ok && basic.Info()&types.IsUntyped != 0 {
+		return types.Default(basic)
+	}
+	return concrete(tv.Type)
+}
+
+// typedRefType returns T of *TypedRef[T], or nil if the type is not a typed reference.
+func (v *vet) typedRefType(typ types.Type) types.Type {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	named, ok := typ.(*types.Named)
+	if !ok || !isGodiType(named, "TypedRef") || named.TypeArgs().Len() != 1 {
+		return nil
+	}
+	return concrete(named.TypeArgs().At(0))
+}
+
+func isGodiType(typ types.Type, name string) bool {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == godiPath && obj.Name() == name
+}
+
+func (v *vet) constSlot(expr ast.Expr) int {
+	tv, ok := v.pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil {
+		return -2
+	}
+	n, ok := constant.Int64Val(constant.ToInt(tv.Value))
+	if !ok || n < 0 {
+		return -2
+	}
+	return int(n)
+}
+
+type slot struct {
+	typ types.Type
+	set bool
+}
+
+func (s *slot) settableBy(typ types.Type) bool {
+	return types.AssignableTo(typ, s.typ)
+}
+
+func (s *slot) appendableBy(typ types.Type) bool {
+	slice, ok := s.typ.Underlying().(*types.Slice)
+	return ok && types.AssignableTo(typ, slice.Elem())
+}
+
+// checkArgs mirrors di.Func.AddArgs: slotted arguments are assigned first, then the remaining ones fill the first matching slots.
+// The receiver (of a method) is the first slotted argument.
+func (v *vet) checkArgs(call *ast.CallExpr, sig *types.Signature, receiver *arg) {
+	if call.Ellipsis.IsValid() {
+		return // The arguments are not known statically.
+	}
+
+	slots := make([]*slot, sig.Params().Len())
+	for i := range slots {
+		slots[i] = &slot{typ: sig.Params().At(i).Type()}
+	}
+
+	args := make([]arg, 0, len(call.Args))
+	if receiver != nil {
+		args = append(args, *receiver)
+	}
+	for _, expr := range call.Args[1:] {
+		args = append(args, v.parseArg(expr))
+	}
+
+	// Once an argument of an unknown type or slot is assigned, the state of the slots is not known anymore.
+	known := true
+	for _, a := range args {
+		switch {
+		case a.slot == -2:
+			known = false
+		case a.slot >= 0:
+			if a.slot >= len(slots) {
+				v.pass.Reportf(a.expr.Pos(), "argument %s is assigned to slot %d, but function has only %d argument slots", v.argString(a), a.slot, len(slots))
+				continue
+			}
+			s := slots[a.slot]
+			switch {
+			case a.typ == nil:
+				known = false
+			case s.settableBy(a.typ):
+				s.set = true
+			case s.appendableBy(a.typ):
+			default:
+				v.pass.Reportf(a.expr.Pos(), "argument %s cannot fill slot %d", v.typeString(a.typ), a.slot)
+			}
+		}
+	}
+	for _, a := range args {
+		if a.slot != -1 || !known {
+			continue
+		}
+		if a.typ == nil {
+			known = false
+			continue
+		}
+		filled := false
+		for _, s := range slots {
+			if s.set {
+				continue
+			}
+			if s.settableBy(a.typ) {
+				s.set, filled = true, true
+				break
+			}
+			if s.appendableBy(a.typ) {
+				filled = true
+				break
+			}
+		}
+		if !filled {
+			v.pass.Reportf(a.expr.Pos(), "argument %s cannot be slotted to function", v.typeString(a.typ))
+		}
+	}
+}
+
+func (v *vet) argString(a arg) string {
+	if a.typ == nil {
+		return types.ExprString(a.expr)
+	}
+	return v.typeString(a.typ)
+}
+
+func (v *vet) typeString(typ types.Type) string {
+	return types.TypeString(typ, types.RelativeTo(v.pass.Pkg))
+}
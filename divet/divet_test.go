@@ -0,0 +1,13 @@
+package divet_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/michalkurzeja/godi/v2/divet"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), divet.Analyzer, "example.com/vet/a")
+}
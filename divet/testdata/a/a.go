@@ -0,0 +1,143 @@
+package a
+
+import (
+	"errors"
+	"time"
+
+	godi "github.com/michalkurzeja/godi/v2"
+)
+
+type Iface interface {
+	Do() error
+}
+
+type Foo struct{}
+
+func NewFoo() *Foo { return &Foo{} }
+
+func (f *Foo) Do() error { return nil }
+
+func (f *Foo) SetName(name string) {}
+
+func (f *Foo) SetTimeout(timeout time.Duration) error { return nil }
+
+func (f *Foo) Count() int { return 0 }
+
+func (f *Foo) Pair() (int, error) { return 0, nil }
+
+func (f *Foo) unexported() {}
+
+type Bar struct{}
+
+func (b *Bar) SetName(name string) {}
+
+func NewBar(foo *Foo, name string, iface Iface) (*Bar, error) { return &Bar{}, nil }
+
+func NewBarWithOptions(foo *Foo, opts ...string) *Bar { return &Bar{} }
+
+func NewBarWithSlice(foos []*Foo) *Bar { return &Bar{} }
+
+func NewInvalidErr() (*Foo, int) { return nil, 0 }
+
+func NewNothing() {}
+
+func NewTriple() (*Foo, *Bar, error) { return nil, nil, nil }
+
+func Run(foo *Foo, name string) error { return nil }
+
+func SetName(f *Foo, name string) {}
+
+func Wiring(ref *godi.SvcReference, typedRef *godi.TypedRef[*Foo], builder *godi.ArgBuilder, args []any) *godi.Builder {
+	return godi.New().
+		Services(
+			// Factories.
+			godi.Svc(NewFoo),
+			godi.Svc(NewBar),
+			godi.Svc(NewInvalidErr), // want `factory NewInvalidErr may only return an error as a second return value, not int`
+			godi.Svc(NewNothing),    // want `factory NewNothing must return at least one value`
+			godi.Svc(NewTriple),     // want `factory NewTriple must return at most two values`
+			godi.Svc(42),            // want `factory kind must be func, got int`
+			godi.Svc(func() (*Foo, error) { return nil, errors.New("foo") }),
+			godi.Svc(func() (*Foo, string) { return nil, "" }), // want `factory \(func\(\) \(\*Foo, string\) literal\) may only return an error as a second return value, not string`
+
+			// Arguments.
+			godi.Svc(NewBar, "name"),
+			godi.Svc(NewBar, "name", 42), // want `argument int cannot be slotted to function`
+			godi.Svc(NewBar, godi.Val("name").Slot(1)),
+			godi.Svc(NewBar, godi.Val("name").Slot(3)),                      // want `argument string is assigned to slot 3, but function has only 3 argument slots`
+			godi.Svc(NewBar, godi.Arg(42).Slot(1)),                          // want `argument int cannot fill slot 1`
+			godi.Svc(NewBar, godi.Type[*Foo]().Slot(5)),                     // want `argument \*Foo is assigned to slot 5, but function has only 3 argument slots`
+			godi.Svc(NewBar, godi.Type[*Bar]()),                             // want `argument \*Bar cannot be slotted to function`
+			godi.Svc(NewBar, godi.Type[*Foo](), godi.Named[string]("name")), // Assigned to the free slots.
+			godi.Svc(NewBar, godi.Type[Iface]("label").Slot(2)),
+			godi.Svc(NewBar, godi.Type[*Foo]().Slot(2)), // *Foo implements Iface.
+			godi.Svc(NewBar, "name", "name"),            // want `argument string cannot be slotted to function`
+			godi.Svc(NewBar, typedRef, typedRef.Arg().Slot(2)),
+			godi.Svc(NewBar, typedRef, typedRef, typedRef), // want `argument \*Foo cannot be slotted to function`
+			godi.Svc(NewBar, godi.Ref(ref).Slot(3)),        // want `argument godi.Ref\(ref\).Slot\(3\) is assigned to slot 3, but function has only 3 argument slots`
+			godi.Svc(NewBar, builder.Slot(4)),              // want `argument builder.Slot\(4\) is assigned to slot 4, but function has only 3 argument slots`
+			godi.Svc(NewBarWithOptions, "foo", "bar"),
+			godi.Svc(NewBarWithOptions, []string{"foo"}),
+			godi.Svc(NewBarWithOptions, 42), // want `argument int cannot be slotted to function`
+			godi.Svc(NewBarWithSlice, godi.SliceOf[*Foo]()),
+			godi.Svc(NewBarWithSlice, godi.SliceOf[*Foo]().Reverse().Slot(0)),
+			godi.Svc(NewBarWithSlice, godi.SliceOf[*Bar]().Slot(0)),                              // want `argument \[\]\*Bar cannot fill slot 0`
+			godi.Svc(NewBarWithSlice, godi.Compound[*Foo](godi.Type[*Foo](), godi.Type[*Bar]())), // want `argument \*Bar cannot be assigned to type \*Foo`
+
+			// Statically unknown arguments: no false positives.
+			godi.Svc(NewBar, ref, ref, ref),
+			godi.Svc(NewBar, builder, 42),
+			godi.Svc(NewBar, args...),
+			godi.Svc(NewBar, any(42)),
+
+			// Method calls.
+			godi.Svc(NewFoo).MethodCall((*Foo).SetName, "foo"),
+			godi.Svc(NewFoo).Name("foo").Lazy().MethodCall((*Foo).SetTimeout, time.Second),
+			godi.Svc(NewFoo).MethodCall((*Foo).SetName, 42),                      // want `argument int cannot be slotted to function`
+			godi.Svc(NewFoo).MethodCall((*Foo).SetName, godi.Val("foo").Slot(2)), // want `argument string is assigned to slot 2, but function has only 2 argument slots`
+			godi.Svc(NewFoo).MethodCall((*Foo).Count),                            // want `method \(\*Foo\).Count may only return an error, not int`
+			godi.Svc(NewFoo).MethodCall((*Foo).Pair),                             // want `method \(\*Foo\).Pair must return at most one value`
+			godi.Svc(NewFoo).MethodCall((*Foo).unexported),                       // want `method \(\*Foo\).unexported not found on receiver \*Foo`
+			godi.Svc(NewFoo).MethodCall((*Bar).SetName, "foo"),                   // want `argument \*Foo cannot fill slot 0`
+			godi.Svc(NewFoo).MethodCall(NewFoo().SetName, "foo"),                 // want `method NewFoo\(\).SetName not found on receiver \*Foo`
+			godi.Svc(NewFoo).MethodCall(func(f *Foo) {}),                         // want `method \(func\(f \*Foo\) literal\) not found on receiver \*Foo`
+			godi.Svc(NewFoo).MethodCall(SetName, "foo"),
+			godi.Svc(NewBar).MethodCall((*Foo).SetName, "foo"), // want `argument \*Bar cannot fill slot 0`
+			godi.SvcVal(&Foo{}).MethodCall((*Foo).Do),
+			godi.SvcVal[Iface](&Foo{}).MethodCall(Iface.Do),
+			godi.SvcVal[Iface](&Foo{}).MethodCall((*Foo).Do), // want `argument Iface cannot fill slot 0`
+			godi.Provide(func() (*Bar, error) { return nil, nil }).MethodCall((*Bar).SetName, "foo"),
+		).
+		Functions(
+			godi.Func(Run, "foo"),
+			godi.Func(Run, godi.Val("foo").Slot(2)), // want `argument string is assigned to slot 2, but function has only 2 argument slots`
+			godi.Func("Run"),                        // want `function kind must be func, got string`
+		).
+		Bindings(
+			godi.BindType[Iface, *Foo](),
+			godi.BindType[Iface, *Bar](), // want `invalid binding: \*Bar does not implement Iface`
+			godi.BindType[*Foo, *Foo](),  // want `invalid binding: \*Foo is not an interface`
+			godi.BindSlice[Iface, *Foo](),
+			godi.BindSlice[Iface, *Bar](), // want `argument \*Bar cannot be assigned to type Iface`
+			godi.BindArg[Iface](godi.Type[*Foo]()),
+			godi.BindArg[Iface](godi.Type[*Bar]()), // want `invalid binding: \*Bar does not implement Iface`
+			godi.BindArg[Iface](builder),
+		)
+}
+
+func NewGeneric[T any](v T) *T { return &v }
+
+// GenericWiring defines services of types known only at runtime, which are not checked.
+func GenericWiring[T any, I Iface](v T) *godi.Builder {
+	return godi.New().
+		Services(
+			godi.Svc(NewGeneric[T], v),
+			godi.Svc(NewBar, v, godi.Type[T]()),
+			godi.SvcVal(v).MethodCall((*Foo).SetName, "foo"),
+			godi.Svc(NewGeneric[int], "foo"), // want `argument string cannot be slotted to function`
+		).
+		Bindings(
+			godi.BindType[I, T](),
+			godi.BindSlice[Iface, T](),
+		)
+}
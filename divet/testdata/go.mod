@@ -0,0 +1,16 @@
+module example.com/vet
+
+go 1.24
+
+require github.com/michalkurzeja/godi/v2 v2.0.0
+
+require (
+	github.com/dominikbraun/graph v0.23.0 // indirect
+	github.com/elliotchance/orderedmap/v2 v2.7.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/samber/lo v1.49.1 // indirect
+	golang.org/x/exp v0.0.0-20250305212735-054e65f0b394 // indirect
+	golang.org/x/text v0.23.0 // indirect
+)
+
+replace github.com/michalkurzeja/godi/v2 => ../..
@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	context "context"
 	io "io"
 
 	di "github.com/michalkurzeja/godi/v2/di"
@@ -272,6 +273,53 @@ func (_c *Container_ExecuteFunctionsByType_Call) RunAndReturn(run func(reflect.T
 	return _c
 }
 
+// FunctionDefinitions provides a mock function with no fields
+func (_m *Container) FunctionDefinitions() []di.FunctionDefinitionView {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for FunctionDefinitions")
+	}
+
+	var r0 []di.FunctionDefinitionView
+	if rf, ok := ret.Get(0).(func() []di.FunctionDefinitionView); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]di.FunctionDefinitionView)
+		}
+	}
+
+	return r0
+}
+
+// Container_FunctionDefinitions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FunctionDefinitions'
+type Container_FunctionDefinitions_Call struct {
+	*mock.Call
+}
+
+// FunctionDefinitions is a helper method to define mock.On call
+func (_e *Container_Expecter) FunctionDefinitions() *Container_FunctionDefinitions_Call {
+	return &Container_FunctionDefinitions_Call{Call: _e.mock.On("FunctionDefinitions")}
+}
+
+func (_c *Container_FunctionDefinitions_Call) Run(run func()) *Container_FunctionDefinitions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Container_FunctionDefinitions_Call) Return(_a0 []di.FunctionDefinitionView) *Container_FunctionDefinitions_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Container_FunctionDefinitions_Call) RunAndReturn(run func() []di.FunctionDefinitionView) *Container_FunctionDefinitions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetFunctionsIDsByLabel provides a mock function with given fields: label
 func (_m *Container) GetFunctionsIDsByLabel(label v2.Label) []v2.ID {
 	ret := _m.Called(label)
@@ -426,6 +474,64 @@ func (_c *Container_GetService_Call) RunAndReturn(run func(di.ID) (any, error))
 	return _c
 }
 
+// GetServiceByName provides a mock function with given fields: name
+func (_m *Container) GetServiceByName(name string) (any, error) {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetServiceByName")
+	}
+
+	var r0 any
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (any, error)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) any); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(any)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Container_GetServiceByName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetServiceByName'
+type Container_GetServiceByName_Call struct {
+	*mock.Call
+}
+
+// GetServiceByName is a helper method to define mock.On call
+//   - name string
+func (_e *Container_Expecter) GetServiceByName(name interface{}) *Container_GetServiceByName_Call {
+	return &Container_GetServiceByName_Call{Call: _e.mock.On("GetServiceByName", name)}
+}
+
+func (_c *Container_GetServiceByName_Call) Run(run func(name string)) *Container_GetServiceByName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Container_GetServiceByName_Call) Return(_a0 any, _a1 error) *Container_GetServiceByName_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Container_GetServiceByName_Call) RunAndReturn(run func(string) (any, error)) *Container_GetServiceByName_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetServices provides a mock function with given fields: ids
 func (_m *Container) GetServices(ids ...di.ID) ([]any, error) {
 	_va := make([]interface{}, len(ids))
@@ -613,6 +719,65 @@ func (_c *Container_GetServicesByType_Call) RunAndReturn(run func(reflect.Type)
 	return _c
 }
 
+// GetServicesByTypeContext provides a mock function with given fields: ctx, typ
+func (_m *Container) GetServicesByTypeContext(ctx context.Context, typ reflect.Type) ([]any, error) {
+	ret := _m.Called(ctx, typ)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetServicesByTypeContext")
+	}
+
+	var r0 []any
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, reflect.Type) ([]any, error)); ok {
+		return rf(ctx, typ)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, reflect.Type) []any); ok {
+		r0 = rf(ctx, typ)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]any)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, reflect.Type) error); ok {
+		r1 = rf(ctx, typ)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Container_GetServicesByTypeContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetServicesByTypeContext'
+type Container_GetServicesByTypeContext_Call struct {
+	*mock.Call
+}
+
+// GetServicesByTypeContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - typ reflect.Type
+func (_e *Container_Expecter) GetServicesByTypeContext(ctx interface{}, typ interface{}) *Container_GetServicesByTypeContext_Call {
+	return &Container_GetServicesByTypeContext_Call{Call: _e.mock.On("GetServicesByTypeContext", ctx, typ)}
+}
+
+func (_c *Container_GetServicesByTypeContext_Call) Run(run func(ctx context.Context, typ reflect.Type)) *Container_GetServicesByTypeContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(reflect.Type))
+	})
+	return _c
+}
+
+func (_c *Container_GetServicesByTypeContext_Call) Return(_a0 []any, _a1 error) *Container_GetServicesByTypeContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Container_GetServicesByTypeContext_Call) RunAndReturn(run func(context.Context, reflect.Type) ([]any, error)) *Container_GetServicesByTypeContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetServicesIDsByLabel provides a mock function with given fields: label
 func (_m *Container) GetServicesIDsByLabel(label v2.Label) []v2.ID {
 	ret := _m.Called(label)
@@ -709,6 +874,64 @@ func (_c *Container_GetServicesIDsByType_Call) RunAndReturn(run func(reflect.Typ
 	return _c
 }
 
+// GetTaggedServices provides a mock function with given fields: tag
+func (_m *Container) GetTaggedServices(tag di.Label) ([]di.TaggedService, error) {
+	ret := _m.Called(tag)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTaggedServices")
+	}
+
+	var r0 []di.TaggedService
+	var r1 error
+	if rf, ok := ret.Get(0).(func(di.Label) ([]di.TaggedService, error)); ok {
+		return rf(tag)
+	}
+	if rf, ok := ret.Get(0).(func(di.Label) []di.TaggedService); ok {
+		r0 = rf(tag)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]di.TaggedService)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(di.Label) error); ok {
+		r1 = rf(tag)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Container_GetTaggedServices_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTaggedServices'
+type Container_GetTaggedServices_Call struct {
+	*mock.Call
+}
+
+// GetTaggedServices is a helper method to define mock.On call
+//   - tag di.Label
+func (_e *Container_Expecter) GetTaggedServices(tag interface{}) *Container_GetTaggedServices_Call {
+	return &Container_GetTaggedServices_Call{Call: _e.mock.On("GetTaggedServices", tag)}
+}
+
+func (_c *Container_GetTaggedServices_Call) Run(run func(tag di.Label)) *Container_GetTaggedServices_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(di.Label))
+	})
+	return _c
+}
+
+func (_c *Container_GetTaggedServices_Call) Return(_a0 []di.TaggedService, _a1 error) *Container_GetTaggedServices_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Container_GetTaggedServices_Call) RunAndReturn(run func(di.Label) ([]di.TaggedService, error)) *Container_GetTaggedServices_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // HasFunction provides a mock function with given fields: id
 func (_m *Container) HasFunction(id di.ID) bool {
 	ret := _m.Called(id)
@@ -801,6 +1024,52 @@ func (_c *Container_HasService_Call) RunAndReturn(run func(di.ID) bool) *Contain
 	return _c
 }
 
+// OnRefresh provides a mock function with given fields: handlers
+func (_m *Container) OnRefresh(handlers ...di.RefreshHandler) {
+	_va := make([]interface{}, len(handlers))
+	for _i := range handlers {
+		_va[_i] = handlers[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
+// Container_OnRefresh_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'OnRefresh'
+type Container_OnRefresh_Call struct {
+	*mock.Call
+}
+
+// OnRefresh is a helper method to define mock.On call
+//   - handlers ...di.RefreshHandler
+func (_e *Container_Expecter) OnRefresh(handlers ...interface{}) *Container_OnRefresh_Call {
+	return &Container_OnRefresh_Call{Call: _e.mock.On("OnRefresh",
+		append([]interface{}{}, handlers...)...)}
+}
+
+func (_c *Container_OnRefresh_Call) Run(run func(handlers ...di.RefreshHandler)) *Container_OnRefresh_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]di.RefreshHandler, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(di.RefreshHandler)
+			}
+		}
+		run(variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Container_OnRefresh_Call) Return() *Container_OnRefresh_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Container_OnRefresh_Call) RunAndReturn(run func(...di.RefreshHandler)) *Container_OnRefresh_Call {
+	_c.Run(run)
+	return _c
+}
+
 // Print provides a mock function with given fields: w
 func (_m *Container) Print(w io.Writer) {
 	_m.Called(w)
@@ -834,6 +1103,159 @@ func (_c *Container_Print_Call) RunAndReturn(run func(io.Writer)) *Container_Pri
 	return _c
 }
 
+// Refresh provides a mock function with given fields: ids
+func (_m *Container) Refresh(ids ...di.ID) error {
+	_va := make([]interface{}, len(ids))
+	for _i := range ids {
+		_va[_i] = ids[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(...di.ID) error); ok {
+		r0 = rf(ids...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Container_Refresh_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Refresh'
+type Container_Refresh_Call struct {
+	*mock.Call
+}
+
+// Refresh is a helper method to define mock.On call
+//   - ids ...di.ID
+func (_e *Container_Expecter) Refresh(ids ...interface{}) *Container_Refresh_Call {
+	return &Container_Refresh_Call{Call: _e.mock.On("Refresh",
+		append([]interface{}{}, ids...)...)}
+}
+
+func (_c *Container_Refresh_Call) Run(run func(ids ...di.ID)) *Container_Refresh_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]di.ID, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(di.ID)
+			}
+		}
+		run(variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Container_Refresh_Call) Return(_a0 error) *Container_Refresh_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Container_Refresh_Call) RunAndReturn(run func(...di.ID) error) *Container_Refresh_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ServiceDefinitions provides a mock function with no fields
+func (_m *Container) ServiceDefinitions() []di.ServiceDefinitionView {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ServiceDefinitions")
+	}
+
+	var r0 []di.ServiceDefinitionView
+	if rf, ok := ret.Get(0).(func() []di.ServiceDefinitionView); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]di.ServiceDefinitionView)
+		}
+	}
+
+	return r0
+}
+
+// Container_ServiceDefinitions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ServiceDefinitions'
+type Container_ServiceDefinitions_Call struct {
+	*mock.Call
+}
+
+// ServiceDefinitions is a helper method to define mock.On call
+func (_e *Container_Expecter) ServiceDefinitions() *Container_ServiceDefinitions_Call {
+	return &Container_ServiceDefinitions_Call{Call: _e.mock.On("ServiceDefinitions")}
+}
+
+func (_c *Container_ServiceDefinitions_Call) Run(run func()) *Container_ServiceDefinitions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Container_ServiceDefinitions_Call) Return(_a0 []di.ServiceDefinitionView) *Container_ServiceDefinitions_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Container_ServiceDefinitions_Call) RunAndReturn(run func() []di.ServiceDefinitionView) *Container_ServiceDefinitions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Stats provides a mock function with no fields
+func (_m *Container) Stats() map[v2.ID]v2.DefinitionStats {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Stats")
+	}
+
+	var r0 map[v2.ID]v2.DefinitionStats
+	if rf, ok := ret.Get(0).(func() map[v2.ID]v2.DefinitionStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[v2.ID]v2.DefinitionStats)
+		}
+	}
+
+	return r0
+}
+
+// Container_Stats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Stats'
+type Container_Stats_Call struct {
+	*mock.Call
+}
+
+// Stats is a helper method to define mock.On call
+func (_e *Container_Expecter) Stats() *Container_Stats_Call {
+	return &Container_Stats_Call{Call: _e.mock.On("Stats")}
+}
+
+func (_c *Container_Stats_Call) Run(run func()) *Container_Stats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Container_Stats_Call) Return(_a0 map[v2.ID]v2.DefinitionStats) *Container_Stats_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Container_Stats_Call) RunAndReturn(run func() map[v2.ID]v2.DefinitionStats) *Container_Stats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewContainer creates a new instance of Container. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewContainer(t interface {
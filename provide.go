@@ -0,0 +1,91 @@
+package di
+
+// TypedArg is an argument of type T, for the typed service registration helpers (see Provide1 and others).
+// The zero value is autowired.
+type TypedArg[T any] struct {
+	builder *ArgBuilder
+}
+
+// Auto returns an argument of type T that is autowired.
+func Auto[T any]() TypedArg[T] {
+	return TypedArg[T]{}
+}
+
+// ValOf returns an argument with a literal value.
+func ValOf[T any](v T) TypedArg[T] {
+	return TypedArg[T]{builder: Val(v)}
+}
+
+// RefOf returns an argument that references the service bound to the typed reference.
+func RefOf[T any](ref *TypedRef[T]) TypedArg[T] {
+	return TypedArg[T]{builder: ref.Arg()}
+}
+
+// ArgOf returns an argument of type T built by the given builder, e.g. ArgOf[[]Handler](SliceOf[Handler]()).
+// The type of the built argument is checked against T when the service definition is built.
+func ArgOf[T any](builder *ArgBuilder) TypedArg[T] {
+	return TypedArg[T]{builder: builder}
+}
+
+// slotted returns a copy of the argument builder, slotted at the given position, or nil if the argument is autowired.
+func (a TypedArg[T]) slotted(i uint) any {
+	if a.builder == nil {
+		return nil
+	}
+	builder := *a.builder
+	return builder.Slot(i)
+}
+
+func provide(factory any, args ...interface{ slotted(uint) any }) *ServiceDefinitionBuilder {
+	var slotted []any
+	for i, arg := range args {
+		if a := arg.slotted(uint(i)); a != nil {
+			slotted = append(slotted, a)
+		}
+	}
+	return Svc(factory, slotted...)
+}
+
+// Provide creates a new ServiceDefinitionBuilder, like Svc, with a factory that is checked at compile time.
+func Provide[T any](factory func() (T, error)) *ServiceDefinitionBuilder {
+	return provide(factory)
+}
+
+// Provide1 creates a new ServiceDefinitionBuilder, like Svc, with a factory and its arguments checked at compile time.
+func Provide1[T, A any](factory func(A) (T, error), a TypedArg[A]) *ServiceDefinitionBuilder {
+	return provide(factory, a)
+}
+
+// Provide2 creates a new ServiceDefinitionBuilder, like Svc, with a factory and its arguments checked at compile time.
+func Provide2[T, A, B any](factory func(A, B) (T, error), a TypedArg[A], b TypedArg[B]) *ServiceDefinitionBuilder {
+	return provide(factory, a, b)
+}
+
+// Provide3 creates a new ServiceDefinitionBuilder, like Svc, with a factory and its arguments checked at compile time.
+func Provide3[T, A, B, C any](factory func(A, B, C) (T, error), a TypedArg[A], b TypedArg[B], c TypedArg[C]) *ServiceDefinitionBuilder {
+	return provide(factory, a, b, c)
+}
+
+// Provide4 creates a new ServiceDefinitionBuilder, like Svc, with a factory and its arguments checked at compile time.
+func Provide4[T, A, B, C, D any](
+	factory func(A, B, C, D) (T, error),
+	a TypedArg[A], b TypedArg[B], c TypedArg[C], d TypedArg[D],
+) *ServiceDefinitionBuilder {
+	return provide(factory, a, b, c, d)
+}
+
+// Provide5 creates a new ServiceDefinitionBuilder, like Svc, with a factory and its arguments checked at compile time.
+func Provide5[T, A, B, C, D, E any](
+	factory func(A, B, C, D, E) (T, error),
+	a TypedArg[A], b TypedArg[B], c TypedArg[C], d TypedArg[D], e TypedArg[E],
+) *ServiceDefinitionBuilder {
+	return provide(factory, a, b, c, d, e)
+}
+
+// Provide6 creates a new ServiceDefinitionBuilder, like Svc, with a factory and its arguments checked at compile time.
+func Provide6[T, A, B, C, D, E, F any](
+	factory func(A, B, C, D, E, F) (T, error),
+	a TypedArg[A], b TypedArg[B], c TypedArg[C], d TypedArg[D], e TypedArg[E], f TypedArg[F],
+) *ServiceDefinitionBuilder {
+	return provide(factory, a, b, c, d, e, f)
+}